@@ -13,9 +13,9 @@ provider "dominos" {
   phone_number  = "15555555555"
 
   credit_card = {
-    number      = 123456789101112
-    cvv         = 1314
-    date        = "15/16"
+    number      = 4111111111111111
+    cvv         = "123"
+    date        = "01/30"
     postal_code = "18192"
   }
 }
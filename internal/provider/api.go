@@ -0,0 +1,321 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+// errOrderRejected is returned when Dominos answers an order request but
+// refuses it, as opposed to the request failing to get an answer at all.
+var errOrderRejected = errors.New("order rejected by Dominos")
+
+// maxErrorBody is how much of an error response is read and kept.
+const maxErrorBody = 64 << 10
+
+// httpStatusError is returned when Dominos answers with a status other than
+// 2xx and the body isn't one of its own error responses, e.g. a 404 page.
+type httpStatusError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *httpStatusError) Error() string {
+	msg := fmt.Sprintf("%s %s returned %s", e.Method, e.URL, e.Status)
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// apiError is returned when Dominos rejects a request and says why. Each
+// status item carries a code such as "InvalidProductCode" or
+// "PosOrderIncomplete". Problems with individual products are kept
+// separately, so they can be traced back to the item code at fault.
+type apiError struct {
+	StatusItems []statusItem
+	Products    []rejectedProduct
+}
+
+// rejectedProduct is a product Dominos refused, by its index in item_codes.
+type rejectedProduct struct {
+	Index       int
+	Code        string
+	StatusItems []statusItem
+}
+
+func (e *apiError) Error() string {
+	if e.empty() {
+		return errOrderRejected.Error()
+	}
+	reasons := make([]string, 0, len(e.StatusItems)+len(e.Products))
+	for _, item := range e.StatusItems {
+		reasons = append(reasons, item.String())
+	}
+	for _, product := range e.Products {
+		for _, item := range product.StatusItems {
+			reasons = append(reasons, fmt.Sprintf("%s: %s", product.Code, item))
+		}
+	}
+	return fmt.Sprintf("%s: %s", errOrderRejected, strings.Join(reasons, ", "))
+}
+
+func (e *apiError) empty() bool {
+	return len(e.StatusItems) == 0 && len(e.Products) == 0
+}
+
+func (item statusItem) String() string {
+	if item.Message != "" {
+		return fmt.Sprintf("%s (%s)", item.Code, item.Message)
+	}
+	return item.Code
+}
+
+func (e *apiError) Is(target error) bool {
+	return target == errOrderRejected
+}
+
+// newAPIError collects the status items describing why Dominos rejected a
+// request, leaving out the informational ones it sends with every response.
+func newAPIError(items ...[]statusItem) *apiError {
+	e := &apiError{}
+	for _, list := range items {
+		for _, item := range list {
+			if item.Code == "" || informationalStatusCodes[item.Code] {
+				continue
+			}
+			e.StatusItems = append(e.StatusItems, item)
+		}
+	}
+	return e
+}
+
+// addProducts records the products in a response that Dominos found problems
+// with. Products are numbered from 1 in the order item_codes lists them.
+func (e *apiError) addProducts(products []orderResponseProduct) {
+	for _, product := range products {
+		items := newAPIError(product.StatusItems).StatusItems
+		if product.Status != -1 && len(items) == 0 {
+			continue
+		}
+		e.Products = append(e.Products, rejectedProduct{
+			Index:       product.ID - 1,
+			Code:        product.Code,
+			StatusItems: items,
+		})
+	}
+}
+
+// informationalStatusCodes show up in successful responses too.
+var informationalStatusCodes = map[string]bool{
+	"Warning":          true,
+	"AutoAddedOrderId": true,
+}
+
+// checkResponse returns an error if r isn't a 2xx. When the body is a
+// Dominos error response, the error is an *apiError, otherwise it is an
+// *httpStatusError. The body is left open either way.
+func checkResponse(r *http.Response) error {
+	if r.StatusCode >= 200 && r.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(r.Body, maxErrorBody))
+
+	var rejected orderResponse
+	if json.Unmarshal(body, &rejected) == nil {
+		e := newAPIError(rejected.StatusItems, rejected.Order.StatusItems)
+		e.addProducts(rejected.Order.Products)
+		if !e.empty() {
+			return e
+		}
+	}
+
+	// Error pages are often HTML, so only keep a short, single line taste
+	snippet := strings.Join(strings.Fields(string(body)), " ")
+	if len(snippet) > 200 {
+		snippet = snippet[:200] + "..."
+	}
+	return &httpStatusError{
+		Method:     r.Request.Method,
+		URL:        r.Request.URL.Redacted(),
+		StatusCode: r.StatusCode,
+		Status:     r.Status,
+		Body:       snippet,
+	}
+}
+
+// getJSON fetches url and decodes the response into v, checking the status
+// code first.
+func getJSON(ctx context.Context, url string, client *http.Client, v interface{}) error {
+	return get(ctx, url, client, func(r io.Reader) error {
+		return json.NewDecoder(r).Decode(v)
+	})
+}
+
+// getXML is getJSON for the endpoints that answer in XML, like the tracker.
+func getXML(ctx context.Context, url string, client *http.Client, v interface{}) error {
+	return get(ctx, url, client, func(r io.Reader) error {
+		return xml.NewDecoder(r).Decode(v)
+	})
+}
+
+func get(ctx context.Context, url string, client *http.Client, decode func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	authorize(req)
+	r, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	err = checkResponse(r)
+	if err != nil {
+		return err
+	}
+
+	err = decode(r.Body)
+	if err != nil {
+		return &decodeError{URL: r.Request.URL.Redacted(), Err: err}
+	}
+	return nil
+}
+
+// decodeError is returned when Dominos answers, but not with what was
+// expected. Asking again won't help.
+type decodeError struct {
+	URL string
+	Err error
+}
+
+func (e *decodeError) Error() string {
+	return fmt.Sprintf("cannot decode response from %s: %v", e.URL, e.Err)
+}
+
+func (e *decodeError) Unwrap() error {
+	return e.Err
+}
+
+// What a Dominos status code is about, which decides the attribute its
+// diagnostic points at.
+const (
+	statusAboutUnknown = iota
+	statusAboutItems
+	statusAboutStore
+	statusAboutAddress
+	statusAboutPayment
+	statusAboutCustomer
+)
+
+type statusCodeInfo struct {
+	about  int
+	advice string
+}
+
+// statusCodes are the status codes Dominos is known to reject orders with.
+var statusCodes = map[string]statusCodeInfo{
+	"InvalidProductCode":         {statusAboutItems, "Check item_codes against the dominos_menu data source for this store."},
+	"ProductUnavailable":         {statusAboutItems, "The store isn't selling one of the items right now. Remove it from item_codes or try again later."},
+	"BelowMinimumDeliveryAmount": {statusAboutItems, "The order is below the store's minimum for delivery. Add more items to item_codes."},
+	"PosOrderIncomplete":         {statusAboutStore, "The store couldn't take the order. It may be closed or not taking online orders right now; try again later or use another store."},
+	"StoreClosed":                {statusAboutStore, "The store is closed. Try again when it's open, or use another store."},
+	"ServiceMethodNotAllowed":    {statusAboutStore, "The store isn't taking delivery orders right now. Try again later, or use another store."},
+	"InvalidAddress":             {statusAboutAddress, "Dominos doesn't recognise the address. Check the address given to the dominos_address data source."},
+	"AddressOutOfArea":           {statusAboutAddress, "The store doesn't deliver to this address. Use the store from the dominos_store data source for it."},
+	"CardDeclined":               {statusAboutPayment, "The card was declined. Check the credit_card or payment_profile in the provider block."},
+	"InvalidCreditCard":          {statusAboutPayment, "Check the number, date, cvv and postal_code of the credit_card or payment_profile in the provider block."},
+	"InvalidEmail":               {statusAboutCustomer, "Check email_address in the order's customer block, or in the provider block if the order doesn't set it."},
+	"InvalidPhone":               {statusAboutCustomer, "Check phone_number in the order's customer block, or in the provider block if the order doesn't set it."},
+}
+
+// productStatusCodes are the reasons Dominos gives for refusing a single
+// product, in words.
+var productStatusCodes = map[string]string{
+	"ProductUnavailable":  "is unavailable at this store right now",
+	"ProductNotAvailable": "is unavailable at this store right now",
+	"InvalidProductCode":  "is not on this store's menu",
+	"InvalidOption":       "has an option the store doesn't offer",
+	"InvalidOptionCode":   "has an option the store doesn't offer",
+	"SizeNotOffered":      "is a size the store doesn't offer",
+	"InvalidSize":         "is a size the store doesn't offer",
+}
+
+// orderErrorDiagnostics turns an error from pricing or placing an order of
+// itemCount items into diagnostics. Reasons Dominos gives for rejecting the
+// order are reported one by one, on the attribute that needs fixing where
+// there is one.
+func orderErrorDiagnostics(summary string, err error, itemCount int) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	var rejected *apiError
+	if !errors.As(err, &rejected) || rejected.empty() {
+		diags.AddError(summary, err.Error())
+		return diags
+	}
+
+	for _, product := range rejected.Products {
+		// Products Dominos added itself, like a reward, aren't in item_codes
+		at := path.Root("item_codes")
+		if product.Index >= 0 && product.Index < itemCount {
+			at = at.AtListIndex(product.Index)
+		}
+
+		if len(product.StatusItems) == 0 {
+			diags.AddAttributeError(at, summary, fmt.Sprintf("Dominos rejected item %q without saying why.", product.Code))
+			continue
+		}
+		for _, item := range product.StatusItems {
+			reason, ok := productStatusCodes[item.Code]
+			if !ok {
+				reason = "was rejected with " + item.Code
+			}
+			detail := fmt.Sprintf("Item %q %s.", product.Code, reason)
+			if item.Message != "" {
+				detail += " Dominos said: " + item.Message
+			}
+			diags.AddAttributeError(at, summary, detail)
+		}
+	}
+
+	for _, item := range rejected.StatusItems {
+		detail := fmt.Sprintf("Dominos rejected the order with %s.", item.Code)
+		if item.Message != "" {
+			detail = fmt.Sprintf("Dominos rejected the order with %s: %s", item.Code, item.Message)
+		}
+
+		info := statusCodes[item.Code]
+		if info.advice != "" {
+			detail += "\n\n" + info.advice
+		}
+
+		switch info.about {
+		case statusAboutItems:
+			diags.AddAttributeError(path.Root("item_codes"), summary, detail)
+		case statusAboutStore:
+			diags.AddAttributeError(path.Root("store_id"), summary, detail)
+		case statusAboutAddress:
+			diags.AddAttributeError(path.Root("api_object"), summary, detail)
+		case statusAboutCustomer:
+			diags.AddAttributeError(path.Root("customer"), summary, detail)
+		default:
+			// Payment details live in the provider block, which a resource
+			// diagnostic can't point at.
+			diags.AddError(summary, detail)
+		}
+	}
+	return diags
+}
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+)
+
+func testResponse(status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    httptest.NewRequest(http.MethodPost, "https://order.dominos.com/power/price-order", nil),
+	}
+}
+
+func TestCheckResponse(t *testing.T) {
+	tests := map[string]struct {
+		status int
+		body   string
+		want   error
+	}{
+		"success": {
+			status: 200,
+			body:   `{"Status":0}`,
+		},
+		"order status items": {
+			status: 400,
+			body:   `{"Status":-1,"StatusItems":[{"Code":"Warning"},{"Code":"StoreClosed","Message":"Closed for the night"}],"Order":{"StatusItems":[{"Code":"AutoAddedOrderId"},{"Code":"PosOrderIncomplete"}]}}`,
+			want: &apiError{StatusItems: []statusItem{
+				{Code: "StoreClosed", Message: "Closed for the night"},
+				{Code: "PosOrderIncomplete"},
+			}},
+		},
+		"product errors": {
+			status: 400,
+			body:   `{"Status":-1,"Order":{"Products":[{"ID":1,"Code":"14SCREEN","Status":0},{"ID":2,"Code":"XYZ","Status":-1,"StatusItems":[{"Code":"InvalidProductCode"}]},{"ID":3,"Code":"20BCOKE","Status":-1}]}}`,
+			want: &apiError{Products: []rejectedProduct{
+				{Index: 1, Code: "XYZ", StatusItems: []statusItem{{Code: "InvalidProductCode"}}},
+				{Index: 2, Code: "20BCOKE"},
+			}},
+		},
+		"only informational status items": {
+			status: 500,
+			body:   `{"Status":-1,"StatusItems":[{"Code":"Warning"}]}`,
+			want: &httpStatusError{
+				Method:     "POST",
+				URL:        "https://order.dominos.com/power/price-order",
+				StatusCode: 500,
+				Status:     "500 Internal Server Error",
+				Body:       `{"Status":-1,"StatusItems":[{"Code":"Warning"}]}`,
+			},
+		},
+		"html error page": {
+			status: 503,
+			body:   "<html>\n  <body>\n    Service   Unavailable\n  </body>\n</html>",
+			want: &httpStatusError{
+				Method:     "POST",
+				URL:        "https://order.dominos.com/power/price-order",
+				StatusCode: 503,
+				Status:     "503 Service Unavailable",
+				Body:       "<html> <body> Service Unavailable </body> </html>",
+			},
+		},
+		"long error page": {
+			status: 404,
+			body:   strings.Repeat("a", 300),
+			want: &httpStatusError{
+				Method:     "POST",
+				URL:        "https://order.dominos.com/power/price-order",
+				StatusCode: 404,
+				Status:     "404 Not Found",
+				Body:       strings.Repeat("a", 200) + "...",
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := checkResponse(testResponse(tt.status, tt.body))
+			if tt.want == nil {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("got %#v, want %#v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPIErrorIsOrderRejected(t *testing.T) {
+	err := fmt.Errorf("cannot place order: %w", &apiError{StatusItems: []statusItem{{Code: "CardDeclined"}}})
+	if !errors.Is(err, errOrderRejected) {
+		t.Error("an apiError isn't errOrderRejected")
+	}
+	if errors.Is(&httpStatusError{StatusCode: 500}, errOrderRejected) {
+		t.Error("an httpStatusError is errOrderRejected")
+	}
+}
+
+func TestOrderErrorDiagnostics(t *testing.T) {
+	type diagnostic struct {
+		path   path.Path
+		detail string
+	}
+	noPath := path.Empty()
+
+	tests := map[string]struct {
+		err       error
+		itemCount int
+		want      []diagnostic
+	}{
+		"not from Dominos": {
+			err:       errors.New("connection refused"),
+			itemCount: 1,
+			want:      []diagnostic{{noPath, "connection refused"}},
+		},
+		"product by ID": {
+			err:       &apiError{Products: []rejectedProduct{{Index: 1, Code: "XYZ", StatusItems: []statusItem{{Code: "InvalidProductCode"}}}}},
+			itemCount: 2,
+			want:      []diagnostic{{path.Root("item_codes").AtListIndex(1), `Item "XYZ" is not on this store's menu.`}},
+		},
+		"product with unknown code and message": {
+			err:       &apiError{Products: []rejectedProduct{{Index: 0, Code: "14SCREEN", StatusItems: []statusItem{{Code: "Oops", Message: "Try again"}}}}},
+			itemCount: 1,
+			want:      []diagnostic{{path.Root("item_codes").AtListIndex(0), `Item "14SCREEN" was rejected with Oops. Dominos said: Try again`}},
+		},
+		"product without a reason": {
+			err:       &apiError{Products: []rejectedProduct{{Index: 0, Code: "14SCREEN"}}},
+			itemCount: 1,
+			want:      []diagnostic{{path.Root("item_codes").AtListIndex(0), `Dominos rejected item "14SCREEN" without saying why.`}},
+		},
+		"product past the end of item_codes": {
+			err:       &apiError{Products: []rejectedProduct{{Index: 2, Code: "8155", StatusItems: []statusItem{{Code: "ProductUnavailable"}}}}},
+			itemCount: 2,
+			want:      []diagnostic{{path.Root("item_codes"), `Item "8155" is unavailable at this store right now.`}},
+		},
+		"product without an ID": {
+			err:       &apiError{Products: []rejectedProduct{{Index: -1, Code: "XYZ", StatusItems: []statusItem{{Code: "InvalidSize"}}}}},
+			itemCount: 2,
+			want:      []diagnostic{{path.Root("item_codes"), `Item "XYZ" is a size the store doesn't offer.`}},
+		},
+		"items": {
+			err:       &apiError{StatusItems: []statusItem{{Code: "BelowMinimumDeliveryAmount"}}},
+			itemCount: 1,
+			want:      []diagnostic{{path.Root("item_codes"), "Dominos rejected the order with BelowMinimumDeliveryAmount.\n\n" + statusCodes["BelowMinimumDeliveryAmount"].advice}},
+		},
+		"store": {
+			err:       &apiError{StatusItems: []statusItem{{Code: "StoreClosed", Message: "Closed"}}},
+			itemCount: 1,
+			want:      []diagnostic{{path.Root("store_id"), "Dominos rejected the order with StoreClosed: Closed\n\n" + statusCodes["StoreClosed"].advice}},
+		},
+		"address": {
+			err:       &apiError{StatusItems: []statusItem{{Code: "AddressOutOfArea"}}},
+			itemCount: 1,
+			want:      []diagnostic{{path.Root("api_object"), "Dominos rejected the order with AddressOutOfArea.\n\n" + statusCodes["AddressOutOfArea"].advice}},
+		},
+		"payment": {
+			err:       &apiError{StatusItems: []statusItem{{Code: "CardDeclined"}}},
+			itemCount: 1,
+			want:      []diagnostic{{noPath, "Dominos rejected the order with CardDeclined.\n\n" + statusCodes["CardDeclined"].advice}},
+		},
+		"customer": {
+			err:       &apiError{StatusItems: []statusItem{{Code: "InvalidPhone"}}},
+			itemCount: 1,
+			want:      []diagnostic{{path.Root("customer"), "Dominos rejected the order with InvalidPhone.\n\n" + statusCodes["InvalidPhone"].advice}},
+		},
+		"unknown code": {
+			err:       &apiError{StatusItems: []statusItem{{Code: "SomethingNew"}}},
+			itemCount: 1,
+			want:      []diagnostic{{noPath, "Dominos rejected the order with SomethingNew."}},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			diags := orderErrorDiagnostics("Cannot place order", tt.err, tt.itemCount)
+
+			got := []diagnostic{}
+			for _, d := range diags {
+				if d.Summary() != "Cannot place order" {
+					t.Errorf("got summary %q", d.Summary())
+				}
+				at := noPath
+				if withPath, ok := d.(interface{ Path() path.Path }); ok {
+					at = withPath.Path()
+				}
+				got = append(got, diagnostic{at, d.Detail()})
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d diagnostics %v, want %v", len(got), got, tt.want)
+			}
+			for i := range got {
+				if !got[i].path.Equal(tt.want[i].path) || got[i].detail != tt.want[i].detail {
+					t.Errorf("diagnostic %d is %q on %s, want %q on %s", i, got[i].detail, got[i].path, tt.want[i].detail, tt.want[i].path)
+				}
+			}
+		})
+	}
+}
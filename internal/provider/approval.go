@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+type orderApprovalData struct {
+	Token types.String `tfsdk:"token"`
+}
+
+// approvalToken signs an order with the provider's approval secret. The token
+// covers the store, the item codes in order and the menu-priced total, so any
+// change to what is being ordered needs a new approval.
+func approvalToken(secret string, storeID int64, itemCodes []string, totalCents int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d\n%s\n%d", storeID, strings.Join(itemCodes, ","), totalCents)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// needsApproval reports whether an order must carry an approval token, either
+// because the user asked for one or because it is over the approval threshold.
+func (p dominosProvider) needsApproval(approval *orderApprovalData, totalCents int64) bool {
+	if approval != nil {
+		return true
+	}
+	return p.approvalThresholdCents > 0 && totalCents > p.approvalThresholdCents
+}
+
+// checkApproval returns an error unless the order carries a valid approval token.
+func (p dominosProvider) checkApproval(approval *orderApprovalData, storeID int64, itemCodes []string, totalCents int64) error {
+	if p.approvalSecret == "" {
+		return fmt.Errorf("an approval_secret must be configured on the provider to approve orders")
+	}
+	// Unknown tokens have an empty Value too, so check them first
+	if approval != nil && approval.Token.Unknown {
+		return nil
+	}
+	if approval == nil || approval.Token.Null || approval.Token.Value == "" {
+		ask := "Ask an approver for the token from a dominos_order_approval data source with the same store_id and item_codes"
+		if p.approvalThresholdCents > 0 && totalCents > p.approvalThresholdCents {
+			return fmt.Errorf("this order is estimated at %s from menu prices, which is over the approval threshold of %s, and needs an approval token. %s", p.formatCents(totalCents), p.formatCents(p.approvalThresholdCents), ask)
+		}
+		return fmt.Errorf("the order has an approval block, so it needs an approval token. %s", ask)
+	}
+	expected := approvalToken(p.approvalSecret, storeID, itemCodes, totalCents)
+	if !hmac.Equal([]byte(expected), []byte(strings.ToLower(approval.Token.Value))) {
+		return fmt.Errorf("the approval token does not match this order. The store, items or price have changed since it was approved, or it was signed with a different secret")
+	}
+	return nil
+}
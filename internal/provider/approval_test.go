@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestCheckApproval(t *testing.T) {
+	m, _ := lookupMarket("US")
+	p := dominosProvider{market: m, approvalSecret: "s3cret", approvalThresholdCents: 5000}
+	items := []string{"14SCREEN", "20BCOKE"}
+	token := approvalToken(p.approvalSecret, 7940, items, 6000)
+
+	tests := []struct {
+		name       string
+		provider   dominosProvider
+		approval   *orderApprovalData
+		storeID    int64
+		itemCodes  []string
+		totalCents int64
+		wantErr    string
+	}{
+		{name: "matching token", provider: p, approval: &orderApprovalData{Token: types.String{Value: token}}, storeID: 7940, itemCodes: items, totalCents: 6000},
+		{name: "upper case token", provider: p, approval: &orderApprovalData{Token: types.String{Value: strings.ToUpper(token)}}, storeID: 7940, itemCodes: items, totalCents: 6000},
+		{name: "unknown token", provider: p, approval: &orderApprovalData{Token: types.String{Unknown: true}}, storeID: 7940, itemCodes: items, totalCents: 6000},
+		{name: "other store", provider: p, approval: &orderApprovalData{Token: types.String{Value: token}}, storeID: 7941, itemCodes: items, totalCents: 6000, wantErr: "does not match"},
+		{name: "other items", provider: p, approval: &orderApprovalData{Token: types.String{Value: token}}, storeID: 7940, itemCodes: []string{"14SCREEN"}, totalCents: 6000, wantErr: "does not match"},
+		{name: "items reordered", provider: p, approval: &orderApprovalData{Token: types.String{Value: token}}, storeID: 7940, itemCodes: []string{"20BCOKE", "14SCREEN"}, totalCents: 6000, wantErr: "does not match"},
+		{name: "price changed", provider: p, approval: &orderApprovalData{Token: types.String{Value: token}}, storeID: 7940, itemCodes: items, totalCents: 6100, wantErr: "does not match"},
+		{
+			name:       "other secret",
+			provider:   dominosProvider{market: m, approvalSecret: "other", approvalThresholdCents: 5000},
+			approval:   &orderApprovalData{Token: types.String{Value: token}},
+			storeID:    7940,
+			itemCodes:  items,
+			totalCents: 6000,
+			wantErr:    "does not match",
+		},
+		{name: "over threshold without token", provider: p, storeID: 7940, itemCodes: items, totalCents: 6000, wantErr: "over the approval threshold of 50.00 USD"},
+		{name: "approval block without token", provider: p, approval: &orderApprovalData{Token: types.String{Null: true}}, storeID: 7940, itemCodes: items, totalCents: 1000, wantErr: "has an approval block"},
+		{name: "no secret", provider: dominosProvider{market: m}, approval: &orderApprovalData{Token: types.String{Value: token}}, storeID: 7940, itemCodes: items, totalCents: 6000, wantErr: "approval_secret must be configured"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.provider.checkApproval(tt.approval, tt.storeID, tt.itemCodes, tt.totalCents)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNeedsApproval(t *testing.T) {
+	p := dominosProvider{approvalThresholdCents: 5000}
+	if p.needsApproval(nil, 5000) {
+		t.Error("an order at the threshold needs approval")
+	}
+	if !p.needsApproval(nil, 5001) {
+		t.Error("an order over the threshold doesn't need approval")
+	}
+	if !p.needsApproval(&orderApprovalData{}, 100) {
+		t.Error("an order with an approval block doesn't need approval")
+	}
+	if (dominosProvider{}).needsApproval(nil, 1000000) {
+		t.Error("an order needs approval without a threshold")
+	}
+}
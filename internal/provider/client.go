@@ -0,0 +1,221 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMaxRetries = 3
+
+	// retryBaseDelay is the delay before the first retry, doubling after that.
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+
+	// requestTimeout limits a single attempt at a request, and retryBudget
+	// every attempt at it together.
+	requestTimeout = 30 * time.Second
+	retryBudget    = 5 * time.Minute
+)
+
+// newHTTPClient returns the client shared by all data sources and resources.
+// Requests are spaced out to requestsPerSecond (if it's above zero), and safe
+// requests are retried on transient failures.
+func newHTTPClient(maxRetries int, requestsPerSecond float64) *http.Client {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+	base.ResponseHeaderTimeout = requestTimeout
+
+	t := &retryTransport{
+		base:       loggingTransport{base: base},
+		maxRetries: maxRetries,
+	}
+	if requestsPerSecond > 0 {
+		t.limiter = &rateLimiter{interval: time.Duration(float64(time.Second) / requestsPerSecond)}
+	}
+
+	// No client Timeout: it would cover every retry together, including for
+	// place-order, which is never retried. retryTransport times requests out.
+	return &http.Client{
+		Transport: t,
+	}
+}
+
+type retryableKey struct{}
+
+// withRetries marks a non-GET request as safe to retry, i.e. sending it twice
+// has the same effect as sending it once. Never use this for place-order.
+func withRetries(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), retryableKey{}, true))
+}
+
+func retryable(req *http.Request) bool {
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
+		return true
+	}
+	ok, _ := req.Context().Value(retryableKey{}).(bool)
+	return ok
+}
+
+// retryTransport retries safe requests that fail with a connection error, a
+// 429 or a 5xx, with exponential backoff and jitter, honouring Retry-After.
+type retryTransport struct {
+	base       http.RoundTripper
+	maxRetries int
+	limiter    *rateLimiter
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if retryable(req) {
+		attempts += t.maxRetries
+	}
+
+	// Every attempt at a request shares one deadline, on top of each
+	// attempt's own requestTimeout
+	ctx, cancel := context.WithTimeout(req.Context(), retryBudget)
+
+	var resp *http.Response
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := backoff(attempt, resp)
+			// Give up with the last response if the retry would be too late
+			if deadline, _ := ctx.Deadline(); time.Now().Add(delay).After(deadline) {
+				break
+			}
+			logDebug(req.Context(), "Retrying Dominos API request", map[string]interface{}{
+				"http_method": req.Method,
+				"http_url":    req.URL.Redacted(),
+				"attempt":     attempt,
+				"delay_ms":    delay.Milliseconds(),
+			})
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			select {
+			case <-ctx.Done():
+				cancel()
+				return nil, ctx.Err()
+			case <-time.After(delay):
+			}
+
+			if req.GetBody != nil {
+				req = req.Clone(req.Context())
+				req.Body, err = req.GetBody()
+				if err != nil {
+					cancel()
+					return nil, err
+				}
+			}
+		}
+
+		if t.limiter != nil {
+			err = t.limiter.wait(ctx)
+			if err != nil {
+				cancel()
+				return nil, err
+			}
+		}
+
+		attemptCtx, cancelAttempt := context.WithTimeout(ctx, requestTimeout)
+		resp, err = t.base.RoundTrip(req.WithContext(attemptCtx))
+		if err != nil {
+			cancelAttempt()
+			// Don't retry when the caller gave up or the retries ran out of time
+			if ctx.Err() != nil {
+				cancel()
+				return nil, err
+			}
+			resp = nil
+			continue
+		}
+		resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancelAttempt}
+
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			break
+		}
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose cancels a request's context once its body has been read,
+// since cancelling it any earlier would cut the body off.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// backoff returns how long to wait before the given retry. A Retry-After
+// header on the previous response wins over the exponential backoff.
+func backoff(attempt int, prev *http.Response) time.Duration {
+	if prev != nil {
+		if after := prev.Header.Get("Retry-After"); after != "" {
+			if seconds, err := strconv.Atoi(after); err == nil {
+				return time.Duration(seconds) * time.Second
+			}
+			if at, err := http.ParseTime(after); err == nil {
+				return time.Until(at)
+			}
+		}
+	}
+
+	delay := retryBaseDelay << (attempt - 1)
+	if delay > retryMaxDelay || delay <= 0 {
+		delay = retryMaxDelay
+	}
+	// Full jitter, so parallel data sources don't retry in lockstep
+	jitter.Lock()
+	defer jitter.Unlock()
+	return time.Duration(jitter.Int63n(int64(delay)))
+}
+
+// jitter is seeded per process, since the global math/rand source always
+// starts from the same seed.
+var jitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// rateLimiter spaces requests out by at least interval.
+type rateLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	at := l.next
+	if at.Before(now) {
+		at = now
+	}
+	l.next = at.Add(l.interval)
+	l.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Until(at)):
+		return nil
+	}
+}
@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer answers with statuses in turn, then 200 once they run out,
+// and counts the requests it gets. Retry-After: 0 keeps retries instant.
+func flakyServer(t *testing.T, statuses ...int) (*httptest.Server, *int32) {
+	t.Helper()
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&calls, 1)
+		if int(n) <= len(statuses) {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func TestRetryTransportRetries(t *testing.T) {
+	tests := map[string]struct {
+		statuses   []int
+		maxRetries int
+		wantStatus int
+		wantCalls  int32
+	}{
+		"success":                 {maxRetries: 3, wantStatus: http.StatusOK, wantCalls: 1},
+		"429 then success":        {statuses: []int{429}, maxRetries: 3, wantStatus: http.StatusOK, wantCalls: 2},
+		"5xx then success":        {statuses: []int{500, 502, 503}, maxRetries: 3, wantStatus: http.StatusOK, wantCalls: 4},
+		"stops at max_retries":    {statuses: []int{503, 503, 503, 503}, maxRetries: 2, wantStatus: http.StatusServiceUnavailable, wantCalls: 3},
+		"no retries":              {statuses: []int{503}, maxRetries: 0, wantStatus: http.StatusServiceUnavailable, wantCalls: 1},
+		"4xx is not retried":      {statuses: []int{404}, maxRetries: 3, wantStatus: http.StatusNotFound, wantCalls: 1},
+		"bad request not retried": {statuses: []int{400}, maxRetries: 3, wantStatus: http.StatusBadRequest, wantCalls: 1},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server, calls := flakyServer(t, tt.statuses...)
+			client := newHTTPClient(tt.maxRetries, 0)
+
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("got status %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := atomic.LoadInt32(calls); got != tt.wantCalls {
+				t.Errorf("server got %d requests, want %d", got, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRetryTransportPost(t *testing.T) {
+	t.Run("not retried without withRetries", func(t *testing.T) {
+		server, calls := flakyServer(t, 503)
+		client := newHTTPClient(3, 0)
+
+		resp, err := client.Post(server.URL+"/power/place-order", "application/json", strings.NewReader(`{"Order":{}}`))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("got status %d, want 503", resp.StatusCode)
+		}
+		if got := atomic.LoadInt32(calls); got != 1 {
+			t.Errorf("place-order was sent %d times, want exactly once", got)
+		}
+	})
+
+	t.Run("retried with withRetries, body and all", func(t *testing.T) {
+		server, calls := flakyServer(t, 503)
+		client := newHTTPClient(3, 0)
+
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/power/price-order", strings.NewReader(`{"Order":{}}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(withRetries(req))
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if got := atomic.LoadInt32(calls); got != 2 {
+			t.Errorf("server got %d requests, want 2", got)
+		}
+		if string(body) != `{"Order":{}}` {
+			t.Errorf("the retry sent body %q, want the original", body)
+		}
+	})
+}
+
+func TestRetryTransportConnectionReset(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			// Drop the connection without answering
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err == nil {
+				conn.Close()
+			}
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	resp, err := newHTTPClient(3, 0).Get(server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("got status %d, want 200", resp.StatusCode)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Errorf("server got %d requests, want 2", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	withHeader := func(value string) *http.Response {
+		return &http.Response{Header: http.Header{"Retry-After": []string{value}}}
+	}
+
+	if got := backoff(1, withHeader("7")); got != 7*time.Second {
+		t.Errorf("Retry-After: 7 waits %s, want 7s", got)
+	}
+
+	at := time.Now().Add(20 * time.Second).UTC().Format(http.TimeFormat)
+	if got := backoff(1, withHeader(at)); got < 18*time.Second || got > 20*time.Second {
+		t.Errorf("Retry-After: %s waits %s, want about 20s", at, got)
+	}
+
+	for attempt := 1; attempt <= 10; attempt++ {
+		limit := retryBaseDelay << (attempt - 1)
+		if limit > retryMaxDelay {
+			limit = retryMaxDelay
+		}
+		for i := 0; i < 20; i++ {
+			for _, prev := range []*http.Response{nil, withHeader("soon")} {
+				if got := backoff(attempt, prev); got < 0 || got >= limit {
+					t.Fatalf("retry %d waits %s, want under %s", attempt, got, limit)
+				}
+			}
+		}
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	server, calls := flakyServer(t)
+	client := newHTTPClient(0, 20)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	// 4 requests at 20 a second are spaced 50ms apart
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("4 requests took %s, want at least 150ms", elapsed)
+	}
+	if got := atomic.LoadInt32(calls); got != 4 {
+		t.Errorf("server got %d requests, want 4", got)
+	}
+}
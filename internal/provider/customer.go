@@ -0,0 +1,151 @@
+package provider
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// customer is who an order is for, and who Dominos contacts about it.
+type customer struct {
+	firstName string
+	lastName  string
+	email     string
+	phone     string
+}
+
+// customerData is the customer block of a dominos_order. Anything left unset
+// falls back to the provider block.
+type customerData struct {
+	FirstName   types.String `tfsdk:"first_name"`
+	LastName    types.String `tfsdk:"last_name"`
+	EmailAddr   types.String `tfsdk:"email_address"`
+	PhoneNumber types.String `tfsdk:"phone_number"`
+}
+
+func customerAttributes() map[string]tfsdk.Attribute {
+	return map[string]tfsdk.Attribute{
+		"first_name": {
+			Description: "The first name the order is under. Default: the provider's first_name.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"last_name": {
+			Description: "The last name the order is under. Default: the provider's last_name.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"email_address": {
+			Description: "The email address to send order updates and the receipt to. Default: the provider's email_address.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+		"phone_number": {
+			Description: "The phone number Dominos will call if any issues arise. Default: the provider's phone_number.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+	}
+}
+
+// customer resolves an order's customer block against the provider's
+// defaults. c may be nil.
+func (p dominosProvider) customer(c *customerData) customer {
+	resolved := customer{
+		firstName: p.firstName,
+		lastName:  p.lastName,
+		email:     p.emailAddr,
+		phone:     p.phoneNumber,
+	}
+	if c == nil {
+		return resolved
+	}
+
+	if c.FirstName.Value != "" {
+		resolved.firstName = c.FirstName.Value
+	}
+	if c.LastName.Value != "" {
+		resolved.lastName = c.LastName.Value
+	}
+	if c.EmailAddr.Value != "" {
+		resolved.email = c.EmailAddr.Value
+	}
+	if c.PhoneNumber.Value != "" {
+		resolved.phone = c.PhoneNumber.Value
+		if phone, err := p.market.NormalizePhone(c.PhoneNumber.Value); err == nil {
+			resolved.phone = phone
+		}
+	}
+	return resolved
+}
+
+// missing lists the details Dominos needs that neither the order nor the
+// provider set.
+func (c customer) missing() []string {
+	missing := []string{}
+	if c.firstName == "" {
+		missing = append(missing, "first_name")
+	}
+	if c.lastName == "" {
+		missing = append(missing, "last_name")
+	}
+	if c.email == "" {
+		missing = append(missing, "email_address")
+	}
+	if c.phone == "" {
+		missing = append(missing, "phone_number")
+	}
+	return missing
+}
+
+// validateEmail checks addr is a bare email address, e.g. 'me@example.com'.
+func validateEmail(addr string) error {
+	parsed, err := mail.ParseAddress(addr)
+	if err != nil || parsed.Address != addr || !strings.Contains(parsed.Address[strings.LastIndex(parsed.Address, "@"):], ".") {
+		return fmt.Errorf("%q is not a valid email address, e.g. 'me@example.com'", addr)
+	}
+	return nil
+}
+
+// checkCustomer validates the details set in an order's customer block.
+func (p dominosProvider) checkCustomer(c *customerData) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if c == nil {
+		return diags
+	}
+
+	if c.EmailAddr.Value != "" {
+		if err := validateEmail(c.EmailAddr.Value); err != nil {
+			diags.AddAttributeError(path.Root("customer").AtName("email_address"), "Invalid email_address", err.Error())
+		}
+	}
+	if c.PhoneNumber.Value != "" {
+		if _, err := p.market.NormalizePhone(c.PhoneNumber.Value); err != nil {
+			diags.AddAttributeError(path.Root("customer").AtName("phone_number"), "Invalid phone_number", err.Error())
+		}
+	}
+	return diags
+}
+
+func missingCustomerDetail(missing []string) string {
+	return fmt.Sprintf("Placing an order needs %s, set in either the customer block or the provider block.", strings.Join(missing, ", "))
+}
+
+// customerUnknown is true when any detail in the customer block won't be
+// known until apply.
+func customerUnknown(c *customerData) bool {
+	return c != nil && (c.FirstName.Unknown || c.LastName.Unknown || c.EmailAddr.Unknown || c.PhoneNumber.Unknown)
+}
+
+// equal reports whether two customer blocks set the same details.
+func (c *customerData) equal(other *customerData) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	return c.FirstName.Equal(other.FirstName) && c.LastName.Equal(other.LastName) && c.EmailAddr.Equal(other.EmailAddr) && c.PhoneNumber.Equal(other.PhoneNumber)
+}
@@ -3,11 +3,10 @@ package provider
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-	"log"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -38,14 +37,16 @@ For carryout, this is purely to find the closest store.
 				Required:    true,
 			},
 			"region": {
-				Description: "The region to deliver the pizza to, meaning the province or state. Ex: 'BC'.",
+				Description: "The region to deliver the pizza to, meaning the province or state code of the provider's market. Ex: 'BC'.",
 				Type:        types.StringType,
 				Required:    true,
+				Validators:  []tfsdk.AttributeValidator{regionValidator()},
 			},
 			"postal_code": {
-				Description: "The region to deliver the pizza to (or zip for the USA). Ex: 'A1A1A1'.",
+				Description: "The postal code to deliver the pizza to (or zip for the USA), in the provider's market. Spaces are removed and letters upper cased in the url_object and api_object. Ex: 'A1A1A1'.",
 				Type:        types.StringType,
 				Required:    true,
+				Validators:  []tfsdk.AttributeValidator{postalCodeValidator()},
 			},
 			"type": {
 				Description: "The type of location to deliver to. Default: 'House'.",
@@ -100,28 +101,45 @@ func (d dataSourceAddress) Read(ctx context.Context, req datasource.ReadRequest,
 		return
 	}
 
+	region, err := d.provider.market.NormalizeRegion(data.Region.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("region"), "Invalid region", err.Error())
+	}
+	postalCode, err := d.provider.market.NormalizePostalCode(data.PostalCode.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("postal_code"), "Invalid postal_code", err.Error())
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// region and postal_code are kept as configured, Terraform doesn't allow
+	// a data source to change its arguments
+	line1, line2 := d.provider.market.AddressLines(data.Street.Value, data.City.Value, region, postalCode)
 	urlobj := map[string]string{
-		"line1": data.Street.Value,
-		"line2": fmt.Sprintf("%s, %s %s", data.City.Value, data.Region.Value, data.PostalCode.Value),
+		"line1": line1,
+		"line2": line2,
 	}
 	apiobj := map[string]string{
 		"Street":     data.Street.Value,
 		"City":       data.City.Value,
-		"Region":     data.Region.Value,
-		"PostalCode": data.PostalCode.Value,
+		"Region":     region,
+		"PostalCode": postalCode,
 		"Type":       data.Type.Value,
 	}
 	url_json, err := json.Marshal(urlobj)
 	if err != nil {
-		log.Fatalf("Cannot unmarshall urlobj")
-
+		resp.Diagnostics.AddError("Cannot encode address", err.Error())
+		return
 	}
 
 	data.URLObject = types.String{Value: string(url_json)}
 
 	api_json, err := json.Marshal(apiobj)
 	if err != nil {
-		log.Fatalf("Cannot unmarshall apiobj")
+		resp.Diagnostics.AddError("Cannot encode address", err.Error())
+		return
 	}
 
 	data.APIObject = types.String{Value: string(api_json)}
@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ provider.DataSourceType = dataSourceLoyaltyType{}
+var _ datasource.DataSource = dataSourceLoyalty{}
+
+type dataSourceLoyaltyType struct{}
+
+func (t dataSourceLoyaltyType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		Description: `
+This data source logs in to the rewards account in the provider's loyalty block, and returns its points balance and the rewards it can exchange them for.
+Pass the code of a reward to the redeem_reward of a dominos_order to spend the points on it.
+		`,
+		Attributes: map[string]tfsdk.Attribute{
+			"points": {
+				Description: "The points the account can spend now.",
+				Type:        types.Int64Type,
+				Computed:    true,
+			},
+			"pending_points": {
+				Description: "The points from recent orders that can't be spent yet.",
+				Type:        types.Int64Type,
+				Computed:    true,
+			},
+			"rewards": {
+				Description: "The rewards the account can exchange points for.",
+				Computed:    true,
+				Attributes: tfsdk.ListNestedAttributes(map[string]tfsdk.Attribute{
+					"code": {
+						Description: "The coupon code of the reward, for the redeem_reward of a dominos_order.",
+						Type:        types.StringType,
+						Computed:    true,
+					},
+					"points": {
+						Description: "How many points the reward costs.",
+						Type:        types.Int64Type,
+						Computed:    true,
+					},
+					"redeemable": {
+						Description: "Whether the account has enough points for the reward.",
+						Type:        types.BoolType,
+						Computed:    true,
+					},
+				}),
+			},
+		},
+	}, nil
+}
+
+func (t dataSourceLoyaltyType) NewDataSource(ctx context.Context, in provider.Provider) (datasource.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return dataSourceLoyalty{
+		provider: provider,
+	}, diags
+}
+
+type dataSourceLoyaltyData struct {
+	Points        types.Int64 `tfsdk:"points"`
+	PendingPoints types.Int64 `tfsdk:"pending_points"`
+	Rewards       []reward    `tfsdk:"rewards"`
+}
+
+type reward struct {
+	Code       string `tfsdk:"code"`
+	Points     int64  `tfsdk:"points"`
+	Redeemable bool   `tfsdk:"redeemable"`
+}
+
+type dataSourceLoyalty struct {
+	provider dominosProvider
+}
+
+func (d dataSourceLoyalty) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data dataSourceLoyaltyData
+
+	diags := req.Config.Get(ctx, &data)
+
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if d.provider.loyalty == nil {
+		resp.Diagnostics.AddError("Missing loyalty account", "The provider needs a loyalty block to read a rewards account.")
+		return
+	}
+
+	account, err := d.provider.loyaltyAccount(d.provider.apiContext(ctx, subsystemLoyalty))
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot get loyalty account", err.Error())
+		return
+	}
+
+	balance, err := points("the points balance", account.VestedPointBalance)
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read loyalty account", err.Error())
+		return
+	}
+	pending, err := points("the pending points balance", account.PendingPointBalance)
+	if err != nil {
+		resp.Diagnostics.AddError("Cannot read loyalty account", err.Error())
+		return
+	}
+	data.Points = types.Int64{Value: balance}
+	data.PendingPoints = types.Int64{Value: pending}
+
+	data.Rewards = make([]reward, 0, len(account.LoyaltyCoupons))
+	for _, coupon := range account.LoyaltyCoupons {
+		cost, err := points("the cost of reward "+strconv.Quote(coupon.CouponCode), coupon.PointValue)
+		if err != nil {
+			resp.Diagnostics.AddError("Cannot read loyalty account", err.Error())
+			return
+		}
+		data.Rewards = append(data.Rewards, reward{
+			Code:       coupon.CouponCode,
+			Points:     cost,
+			Redeemable: balance >= cost,
+		})
+	}
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
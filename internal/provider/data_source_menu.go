@@ -4,15 +4,18 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -30,6 +33,8 @@ func (t dataSourceMenuType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.D
 If you would prefer to do your own filtering, you can get access to every item on the dominos menu in your area using this data source.
 This data source takes in store_id and provides menu, a list of all (186, at my dominos) name/code/price_cents blocks.
 
+To plan without reaching Dominos (e.g. in an air-gapped CI), set export_file once to save the store's menu, then use menu_file instead of store_id to read it back.
+
 For the love of all that's holy, do not accidentally feed this data source directly into the dominos_order.
 This will be expensive and probably pretty annoying to the Dominos store, which will be serving you 1 of each 2-liter bottle of soda, 1 of each 20oz bottle, at least 4 different kinds of salad, probably like 6 different kinds of chicken wings, and I think 12 of each kind of pizza?
 (Small, medium, large) x (Hand Tossed, Pan, Stuffed Crust, Gluten Free)?
@@ -38,9 +43,24 @@ I wonder who eats those. Are they good? Let me know!
 		`,
 		Attributes: map[string]tfsdk.Attribute{
 			"store_id": {
-				Description: "The ID of the store to get the menu for.",
+				Description: "The ID of the store to get the menu for. Conflicts with menu_file.",
 				Type:        types.Int64Type,
-				Required:    true,
+				Optional:    true,
+			},
+			"menu_file": {
+				Description: "A local JSON file to read the menu from instead of a store, in the format the Dominos menu endpoint returns (see export_file). Conflicts with store_id.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"export_file": {
+				Description: "Save the store's menu to this file as Dominos returned it, for use as menu_file later.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"language": {
+				Description: "The language to return item names in, e.g. 'fr'. Item codes are the same in every language. Ignored for menu_file. Default: the provider's language.",
+				Type:        types.StringType,
+				Optional:    true,
 			},
 			"menu": {
 				Description: "An array of all menu item for the given store.",
@@ -76,8 +96,11 @@ func (t dataSourceMenuType) NewDataSource(ctx context.Context, in provider.Provi
 }
 
 type dataSourceMenuData struct {
-	StoreID types.Int64 `tfsdk:"store_id"`
-	Menu    []menuItem  `tfsdk:"delivery_minutes"`
+	StoreID    types.Int64  `tfsdk:"store_id"`
+	MenuFile   types.String `tfsdk:"menu_file"`
+	ExportFile types.String `tfsdk:"export_file"`
+	Language   types.String `tfsdk:"language"`
+	Menu       []menuItem   `tfsdk:"menu"`
 }
 
 type dataSourceMenu struct {
@@ -95,11 +118,29 @@ func (d dataSourceMenu) Read(ctx context.Context, req datasource.ReadRequest, re
 		return
 	}
 
-	var client = &http.Client{Timeout: 10 * time.Second}
+	body, diags := d.provider.menuFromSource(ctx, data.StoreID, data.MenuFile, data.Language)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	menuitems, err := getAllMenuItems(fmt.Sprintf("https://order.dominos.com/power/store/%d/menu?lang=en&structured=true", data.StoreID.Value), client)
+	menuitems, err := parseMenuItems(body)
 	if err != nil {
-		log.Fatalf("Cannot get all menu items: %v", err)
+		resp.Diagnostics.AddError("Cannot read menu", err.Error())
+		return
+	}
+
+	if !data.ExportFile.Null && data.ExportFile.Value != "" {
+		if !data.MenuFile.Null {
+			resp.Diagnostics.AddAttributeError(path.Root("export_file"), "Conflicting export_file", "export_file saves a store's menu, so it can't be used with menu_file.")
+			return
+		}
+		err = writeMenuFile(data.ExportFile.Value, body)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("export_file"), "Cannot export menu", err.Error())
+			return
+		}
 	}
 
 	for i := range menuitems {
@@ -110,35 +151,147 @@ func (d dataSourceMenu) Read(ctx context.Context, req datasource.ReadRequest, re
 	resp.Diagnostics.Append(diags...)
 }
 
-func getMenuApiObject(url string, client *http.Client) (map[string]interface{}, error) {
-	r, err := client.Get(url)
+// menuFromSource returns the menu a data source asked for, read from
+// menuFile if it is set, and fetched from the store otherwise.
+func (p dominosProvider) menuFromSource(ctx context.Context, storeID types.Int64, menuFile types.String, language types.String) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if storeID.Null == menuFile.Null {
+		diags.AddError("Invalid menu source", "Exactly one of store_id and menu_file must be set.")
+		return nil, diags
+	}
+
+	if !menuFile.Null {
+		body, err := os.ReadFile(menuFile.Value)
+		if err != nil {
+			diags.AddAttributeError(path.Root("menu_file"), "Cannot read menu_file", err.Error())
+		}
+		return body, diags
+	}
+
+	body, err := p.menuBody(ctx, storeID.Value, language)
+	if err != nil {
+		diags.AddAttributeError(path.Root("store_id"), "Cannot get menu", fmt.Sprintf("The menu for store %d could not be fetched: %v", storeID.Value, err))
+	}
+	return body, diags
+}
+
+// orderMenuBody returns the menu an order is checked against at plan time:
+// its menu_file if it has one, and the store's menu otherwise.
+func (p dominosProvider) orderMenuBody(ctx context.Context, storeID int64, menuFile types.String) ([]byte, error) {
+	if !menuFile.Null && !menuFile.Unknown && menuFile.Value != "" {
+		body, err := os.ReadFile(menuFile.Value)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read menu_file: %w", err)
+		}
+		return body, nil
+	}
+
+	body, err := p.menuBody(ctx, storeID, types.String{Null: true})
+	if err != nil {
+		return nil, fmt.Errorf("cannot get menu for store %d: %w", storeID, err)
+	}
+	return body, nil
+}
+
+// writeMenuFile saves a menu as Dominos returned it, so it can be read back
+// with menu_file.
+func writeMenuFile(path string, body []byte) error {
+	err := os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, body, 0o644)
+}
+
+// menuLanguage resolves a data source's language override against the
+// provider's default language.
+func (p dominosProvider) menuLanguage(language types.String) string {
+	if !language.Null && !language.Unknown && language.Value != "" {
+		return strings.ToLower(language.Value)
+	}
+	return p.language
+}
+
+// menuURL returns the structured menu endpoint for a store.
+func (p dominosProvider) menuURL(storeID int64, lang string) string {
+	return fmt.Sprintf("%s/power/store/%d/menu?lang=%s&structured=true", p.market.APIHost(), storeID, url.QueryEscape(lang))
+}
+
+// menuItems returns every item on a store's menu. Menus are cached for the
+// provider instance, so asking for the same store and language again doesn't
+// download the menu again.
+func (p dominosProvider) menuItems(ctx context.Context, storeID int64, language types.String) ([]menuItem, error) {
+	body, err := p.menuBody(ctx, storeID, language)
+	if err != nil {
+		return nil, err
+	}
+	return parseMenuItems(body)
+}
+
+// menuBody returns a store's menu as Dominos sent it, from the cache if
+// possible.
+func (p dominosProvider) menuBody(ctx context.Context, storeID int64, language types.String) ([]byte, error) {
+	lang := p.menuLanguage(language)
+	key := fmt.Sprintf("menu-%s-%d-%s", p.market.Code(), storeID, lang)
+
+	return p.menus.get(key, func() ([]byte, error) {
+		return getMenu(p.apiContext(ctx, subsystemMenu), p.menuURL(storeID, lang), p.client)
+	})
+}
+
+func getMenu(ctx context.Context, url string, client *http.Client) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	r, err := client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer r.Body.Close()
-	resp := make(map[string]interface{})
-	err = json.NewDecoder(r.Body).Decode(&resp)
-	return resp, err
+
+	err = checkResponse(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(r.Body)
 }
 
-func getAllMenuItems(url string, client *http.Client) ([]menuItem, error) {
-	resp, err := getMenuApiObject(url, client)
+func parseMenuItems(body []byte) ([]menuItem, error) {
+	resp := make(map[string]interface{})
+	err := json.Unmarshal(body, &resp)
 	if err != nil {
 		return nil, err
 	}
-	products := resp["Variants"].(map[string]interface{})
+	products, ok := resp["Variants"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("menu has no Variants")
+	}
 	all_products := make([]menuItem, 0, len(products))
-	for name, d := range products {
-		dict := d.(map[string]interface{})
-		price := dict["Price"].(string)
+	for code, d := range products {
+		// Menus can come from a user's menu_file or snapshot, so don't trust
+		// their shape
+		dict, ok := d.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("menu variant %q is not an object", code)
+		}
+		price, ok := dict["Price"].(string)
+		if !ok {
+			return nil, fmt.Errorf("menu variant %q has no Price, or it isn't a string like \"12.99\"", code)
+		}
+		name, ok := dict["Name"].(string)
+		if !ok {
+			return nil, fmt.Errorf("menu variant %q has no Name, or it isn't a string", code)
+		}
 		price = strings.Replace(price, ".", "", 1)
 		price_cents, err := strconv.ParseInt(price, 10, 64)
 		if err != nil {
 			continue
 		}
 		all_products = append(all_products, menuItem{
-			Code:       name,
-			Name:       dict["Name"].(string),
+			Code:       code,
+			Name:       name,
 			PriceCents: price_cents,
 		})
 	}
@@ -0,0 +1,251 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ provider.DataSourceType = dataSourceMenuDiffType{}
+var _ datasource.DataSource = dataSourceMenuDiff{}
+
+type dataSourceMenuDiffType struct{}
+
+func (t dataSourceMenuDiffType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	menuItemAttributes := map[string]tfsdk.Attribute{
+		"name": {
+			Description: "The name of the item.",
+			Type:        types.StringType,
+			Computed:    true,
+		},
+		"code": {
+			Description: "The dominos code for the item.",
+			Type:        types.StringType,
+			Computed:    true,
+		},
+		"price_cents": {
+			Description: "The price in cents of the item.",
+			Type:        types.Int64Type,
+			Computed:    true,
+		},
+	}
+
+	return tfsdk.Schema{
+		Description: `
+This data source compares a store's menu today against a snapshot saved earlier, so you can find out the large pepperoni went up before the order goes through.
+
+The snapshot is either a menu saved with the export_file of dominos_menu, or the menu attribute of a dominos_menu, e.g. jsonencode(data.dominos_menu.menu.menu).
+Keep it in a file with snapshot_file, or pass it in directly with snapshot_json.
+		`,
+		Attributes: map[string]tfsdk.Attribute{
+			"store_id": {
+				Description: "The ID of the store to compare the menu of.",
+				Type:        types.Int64Type,
+				Required:    true,
+			},
+			"language": {
+				Description: "The language to return item names in, e.g. 'fr'. Default: the provider's language.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"snapshot_file": {
+				Description: "A local JSON file holding the earlier menu. Conflicts with snapshot_json.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"snapshot_json": {
+				Description: "The earlier menu, as a JSON string. Conflicts with snapshot_file.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
+			"added": {
+				Description: "The items on the menu now that weren't in the snapshot.",
+				Computed:    true,
+				Attributes:  tfsdk.ListNestedAttributes(menuItemAttributes),
+			},
+			"removed": {
+				Description: "The items in the snapshot that aren't on the menu any more. Their price is the price in the snapshot.",
+				Computed:    true,
+				Attributes:  tfsdk.ListNestedAttributes(menuItemAttributes),
+			},
+			"price_changes": {
+				Description: "The items whose price changed since the snapshot.",
+				Computed:    true,
+				Attributes: tfsdk.ListNestedAttributes(map[string]tfsdk.Attribute{
+					"name": {
+						Description: "The name of the item.",
+						Type:        types.StringType,
+						Computed:    true,
+					},
+					"code": {
+						Description: "The dominos code for the item.",
+						Type:        types.StringType,
+						Computed:    true,
+					},
+					"old_price_cents": {
+						Description: "The price in cents of the item in the snapshot.",
+						Type:        types.Int64Type,
+						Computed:    true,
+					},
+					"new_price_cents": {
+						Description: "The price in cents of the item now.",
+						Type:        types.Int64Type,
+						Computed:    true,
+					},
+				}),
+			},
+		},
+	}, nil
+}
+
+func (t dataSourceMenuDiffType) NewDataSource(ctx context.Context, in provider.Provider) (datasource.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return dataSourceMenuDiff{
+		provider: provider,
+	}, diags
+}
+
+type dataSourceMenuDiffData struct {
+	StoreID      types.Int64   `tfsdk:"store_id"`
+	Language     types.String  `tfsdk:"language"`
+	SnapshotFile types.String  `tfsdk:"snapshot_file"`
+	SnapshotJSON types.String  `tfsdk:"snapshot_json"`
+	Added        []menuItem    `tfsdk:"added"`
+	Removed      []menuItem    `tfsdk:"removed"`
+	PriceChanges []priceChange `tfsdk:"price_changes"`
+}
+
+type priceChange struct {
+	Name          string `tfsdk:"name"`
+	Code          string `tfsdk:"code"`
+	OldPriceCents int64  `tfsdk:"old_price_cents"`
+	NewPriceCents int64  `tfsdk:"new_price_cents"`
+}
+
+type dataSourceMenuDiff struct {
+	provider dominosProvider
+}
+
+func (d dataSourceMenuDiff) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data dataSourceMenuDiffData
+
+	diags := req.Config.Get(ctx, &data)
+
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.SnapshotFile.Null == data.SnapshotJSON.Null {
+		resp.Diagnostics.AddError("Invalid snapshot", "Exactly one of snapshot_file and snapshot_json must be set.")
+		return
+	}
+
+	snapshotPath := path.Root("snapshot_json")
+	snapshot := []byte(data.SnapshotJSON.Value)
+	if !data.SnapshotFile.Null {
+		snapshotPath = path.Root("snapshot_file")
+
+		var err error
+		snapshot, err = os.ReadFile(data.SnapshotFile.Value)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(snapshotPath, "Cannot read snapshot", err.Error())
+			return
+		}
+	}
+
+	before, err := parseMenuSnapshot(snapshot)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(snapshotPath, "Cannot read snapshot", err.Error())
+		return
+	}
+
+	now, err := d.provider.menuItems(ctx, data.StoreID.Value, data.Language)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("store_id"), "Cannot get menu", err.Error())
+		return
+	}
+
+	data.Added, data.Removed, data.PriceChanges = diffMenus(before, now)
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
+
+// parseMenuSnapshot reads a menu either in the format the menu endpoint
+// returns, or as the list of items in the menu attribute of dominos_menu.
+func parseMenuSnapshot(snapshot []byte) ([]menuItem, error) {
+	if !bytes.HasPrefix(bytes.TrimSpace(snapshot), []byte("[")) {
+		return parseMenuItems(snapshot)
+	}
+
+	var items []struct {
+		Name       string `json:"name"`
+		Code       string `json:"code"`
+		PriceCents int64  `json:"price_cents"`
+	}
+	err := json.Unmarshal(snapshot, &items)
+	if err != nil {
+		return nil, err
+	}
+
+	menu := make([]menuItem, 0, len(items))
+	for _, item := range items {
+		menu = append(menu, menuItem{Name: item.Name, Code: item.Code, PriceCents: item.PriceCents})
+	}
+	return menu, nil
+}
+
+// diffMenus compares two menus by item code. Every list is sorted by code.
+func diffMenus(before, now []menuItem) (added, removed []menuItem, changes []priceChange) {
+	old := make(map[string]menuItem, len(before))
+	for _, item := range before {
+		old[item.Code] = item
+	}
+	current := make(map[string]bool, len(now))
+
+	// Empty rather than nil, so state has empty lists instead of nulls and
+	// length() works on them
+	added, removed, changes = []menuItem{}, []menuItem{}, []priceChange{}
+
+	for _, item := range now {
+		current[item.Code] = true
+
+		was, ok := old[item.Code]
+		if !ok {
+			added = append(added, item)
+			continue
+		}
+		if was.PriceCents != item.PriceCents {
+			changes = append(changes, priceChange{
+				Name:          item.Name,
+				Code:          item.Code,
+				OldPriceCents: was.PriceCents,
+				NewPriceCents: item.PriceCents,
+			})
+		}
+	}
+
+	for _, item := range before {
+		if !current[item.Code] {
+			removed = append(removed, item)
+		}
+	}
+
+	sort.Slice(added, func(i, j int) bool { return added[i].Code < added[j].Code })
+	sort.Slice(removed, func(i, j int) bool { return removed[i].Code < removed[j].Code })
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Code < changes[j].Code })
+	return added, removed, changes
+}
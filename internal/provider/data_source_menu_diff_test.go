@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMenuSnapshot(t *testing.T) {
+	want := []menuItem{{Code: "14SCREEN", Name: "Pizza", PriceCents: 1399}}
+
+	tests := map[string]string{
+		"menu endpoint": `{"Variants": {"14SCREEN": {"Name": "Pizza", "Price": "13.99"}}}`,
+		"menu list":     ` [{"name": "Pizza", "code": "14SCREEN", "price_cents": 1399}]`,
+	}
+	for name, snapshot := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := parseMenuSnapshot([]byte(snapshot))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestParseMenuSnapshotMalformed(t *testing.T) {
+	tests := map[string]string{
+		"empty":                 ``,
+		"truncated":             `{"Variants": {"14SCREEN": `,
+		"variant not an object": `{"Variants": {"14SCREEN": []}}`,
+		"numeric price":         `{"Variants": {"14SCREEN": {"Name": "Pizza", "Price": 13.99}}}`,
+		"list of strings":       `["14SCREEN"]`,
+		"string price_cents":    `[{"name": "Pizza", "code": "14SCREEN", "price_cents": "1399"}]`,
+	}
+	for name, snapshot := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMenuSnapshot([]byte(snapshot))
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestDiffMenus(t *testing.T) {
+	before := []menuItem{
+		{Code: "14SCREEN", Name: "Large Pizza", PriceCents: 1399},
+		{Code: "2LCOKE", Name: "Coke", PriceCents: 349},
+		{Code: "BREAD", Name: "Bread", PriceCents: 599},
+	}
+	now := []menuItem{
+		{Code: "WINGS", Name: "Wings", PriceCents: 899},
+		{Code: "2LCOKE", Name: "Coke", PriceCents: 349},
+		{Code: "14SCREEN", Name: "Large Pizza", PriceCents: 1499},
+		{Code: "12SCREEN", Name: "Medium Pizza", PriceCents: 1199},
+	}
+
+	added, removed, changes := diffMenus(before, now)
+
+	wantAdded := []menuItem{
+		{Code: "12SCREEN", Name: "Medium Pizza", PriceCents: 1199},
+		{Code: "WINGS", Name: "Wings", PriceCents: 899},
+	}
+	wantRemoved := []menuItem{{Code: "BREAD", Name: "Bread", PriceCents: 599}}
+	wantChanges := []priceChange{{Code: "14SCREEN", Name: "Large Pizza", OldPriceCents: 1399, NewPriceCents: 1499}}
+
+	if !reflect.DeepEqual(added, wantAdded) {
+		t.Errorf("added: got %+v, want %+v", added, wantAdded)
+	}
+	if !reflect.DeepEqual(removed, wantRemoved) {
+		t.Errorf("removed: got %+v, want %+v", removed, wantRemoved)
+	}
+	if !reflect.DeepEqual(changes, wantChanges) {
+		t.Errorf("price changes: got %+v, want %+v", changes, wantChanges)
+	}
+}
+
+func TestDiffMenusUnchanged(t *testing.T) {
+	menu := []menuItem{{Code: "14SCREEN", Name: "Large Pizza", PriceCents: 1399}}
+
+	added, removed, changes := diffMenus(menu, menu)
+	if len(added) != 0 || len(removed) != 0 || len(changes) != 0 {
+		t.Errorf("got added %+v, removed %+v, changes %+v, want none", added, removed, changes)
+	}
+	// Nil slices would be null lists in state
+	if added == nil || removed == nil || changes == nil {
+		t.Errorf("got added %#v, removed %#v, changes %#v, want empty slices", added, removed, changes)
+	}
+}
@@ -2,11 +2,7 @@ package provider
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"net/http"
 	"strings"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
@@ -24,18 +20,24 @@ type dataSourceMenuItemType struct{}
 func (t dataSourceMenuItemType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
 	return tfsdk.Schema{
 		Description: `
-This data source takes in the store_id and a list of strings (as query_string), and outputs the menu items in matches.
+This data source takes in the store_id (or a menu_file saved by dominos_menu) and a list of strings (as query_string), and outputs the menu items in matches.
 Each item in matches has three attributes: name, code, and price_cents.
 The name is human-readable, but not useful for ordering.
 The price_cents is also only informational.
 
 Each string in query_string must literally match the name of the menu item for the menu item to appear in matches.
+Names are matched in the requested language, so set language = "fr" to query with French names.
 		`,
 		Attributes: map[string]tfsdk.Attribute{
 			"store_id": {
-				Description: "The ID of the store to get the menu for.",
+				Description: "The ID of the store to get the menu for. Conflicts with menu_file.",
 				Type:        types.Int64Type,
-				Required:    true,
+				Optional:    true,
+			},
+			"menu_file": {
+				Description: "A local JSON file to read the menu from instead of a store, as saved by the export_file of dominos_menu. Conflicts with store_id.",
+				Type:        types.StringType,
+				Optional:    true,
 			},
 			"query_string": {
 				Description: "Each string in query_string must literally match the name of the menu item for the menu item to appear in matches.",
@@ -44,6 +46,11 @@ Each string in query_string must literally match the name of the menu item for t
 				},
 				Required: true,
 			},
+			"language": {
+				Description: "The language to match and return item names in, e.g. 'fr'. Item codes are the same in every language. Ignored for menu_file. Default: the provider's language.",
+				Type:        types.StringType,
+				Optional:    true,
+			},
 			"matches": {
 				Description: "An array of all possible menu item that matches the given query string.",
 				Computed:    true,
@@ -79,7 +86,9 @@ func (t dataSourceMenuItemType) NewDataSource(ctx context.Context, in provider.P
 
 type dataSourceMenuItemData struct {
 	StoreID     types.Int64    `tfsdk:"store_id"`
+	MenuFile    types.String   `tfsdk:"menu_file"`
 	QueryString []types.String `tfsdk:"query_string"`
+	Language    types.String   `tfsdk:"language"`
 	Matches     []menuItem     `tfsdk:"matches"`
 }
 
@@ -104,10 +113,17 @@ func (d dataSourceMenuItem) Read(ctx context.Context, req datasource.ReadRequest
 		return
 	}
 
-	var client = &http.Client{Timeout: 10 * time.Second}
-	menuitems, err := getAllMenuItems(fmt.Sprintf("https://order.dominos.com/power/store/%d/menu?lang=en&structured=true", data.StoreID.Value), client)
+	body, diags := d.provider.menuFromSource(ctx, data.StoreID, data.MenuFile, data.Language)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	menuitems, err := parseMenuItems(body)
 	if err != nil {
-		log.Fatalf("Cannot get all menu items: %v", err)
+		resp.Diagnostics.AddError("Cannot read menu", err.Error())
+		return
 	}
 
 	queries := data.QueryString
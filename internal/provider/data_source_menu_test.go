@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMenuItems(t *testing.T) {
+	items, err := parseMenuItems([]byte(`{"Variants": {
+		"14SCREEN": {"Name": "Large (14\") Hand Tossed Pizza", "Price": "13.99"},
+		"2LCOKE": {"Name": "Coke", "Price": "3.49"}
+	}}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []menuItem{
+		{Code: "14SCREEN", Name: "Large (14\") Hand Tossed Pizza", PriceCents: 1399},
+		{Code: "2LCOKE", Name: "Coke", PriceCents: 349},
+	}
+	if !reflect.DeepEqual(items, want) {
+		t.Errorf("got %+v, want %+v", items, want)
+	}
+}
+
+func TestParseMenuItemsMalformed(t *testing.T) {
+	tests := map[string]struct {
+		menu string
+		err  string
+	}{
+		"not json": {
+			menu: `{"Variants":`,
+			err:  "unexpected end of JSON input",
+		},
+		"no variants": {
+			menu: `{"Products": {}}`,
+			err:  "menu has no Variants",
+		},
+		"variant not an object": {
+			menu: `{"Variants": {"14SCREEN": "13.99"}}`,
+			err:  `"14SCREEN" is not an object`,
+		},
+		"numeric price": {
+			menu: `{"Variants": {"14SCREEN": {"Name": "Pizza", "Price": 13.99}}}`,
+			err:  `"14SCREEN" has no Price`,
+		},
+		"missing name": {
+			menu: `{"Variants": {"14SCREEN": {"Price": "13.99"}}}`,
+			err:  `"14SCREEN" has no Name`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parseMenuItems([]byte(tt.menu))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("got error %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
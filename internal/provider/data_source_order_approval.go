@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces
+var _ provider.DataSourceType = dataSourceOrderApprovalType{}
+var _ datasource.DataSource = dataSourceOrderApproval{}
+
+type dataSourceOrderApprovalType struct{}
+
+func (t dataSourceOrderApprovalType) GetSchema(ctx context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return tfsdk.Schema{
+		Description: `
+This data source is for the person approving an order. Given the same store_id and item_codes as the dominos_order, it prices the order and signs it with the provider's approval_secret.
+Hand the token over to whoever is placing the order, and they can put it in the approval block of their dominos_order.
+
+The token is only valid for that exact store, list of items and price. If anything changes, the order needs to be approved again.
+		`,
+		Attributes: map[string]tfsdk.Attribute{
+			"store_id": {
+				Description: "The ID of the store that the order is for.",
+				Type:        types.Int64Type,
+				Required:    true,
+			},
+			"item_codes": {
+				Description: "An array of menu items to approve, in the same order as the dominos_order.",
+				Type: types.ListType{
+					ElemType: types.StringType,
+				},
+				Required: true,
+			},
+			"total_price_cents": {
+				Description: "The price in cents of the items being approved, before taxes and fees.",
+				Type:        types.Int64Type,
+				Computed:    true,
+			},
+			"token": {
+				Description: "The approval token for the order.",
+				Type:        types.StringType,
+				Computed:    true,
+				Sensitive:   true,
+			},
+		},
+	}, nil
+}
+
+func (t dataSourceOrderApprovalType) NewDataSource(ctx context.Context, in provider.Provider) (datasource.DataSource, diag.Diagnostics) {
+	provider, diags := convertProviderType(in)
+
+	return dataSourceOrderApproval{
+		provider: provider,
+	}, diags
+}
+
+type dataSourceOrderApprovalData struct {
+	StoreID         types.Int64  `tfsdk:"store_id"`
+	ItemCodes       []string     `tfsdk:"item_codes"`
+	TotalPriceCents types.Int64  `tfsdk:"total_price_cents"`
+	Token           types.String `tfsdk:"token"`
+}
+
+type dataSourceOrderApproval struct {
+	provider dominosProvider
+}
+
+func (d dataSourceOrderApproval) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data dataSourceOrderApprovalData
+
+	diags := req.Config.Get(ctx, &data)
+
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if d.provider.approvalSecret == "" {
+		resp.Diagnostics.AddError("Missing approval_secret", "An approval_secret must be configured on the provider to approve orders.")
+		return
+	}
+
+	totalCents, err := d.provider.menuTotalCents(ctx, data.StoreID.Value, types.String{Null: true}, data.ItemCodes)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("item_codes"), "Cannot price order", err.Error())
+		return
+	}
+
+	data.TotalPriceCents = types.Int64{Value: totalCents}
+	data.Token = types.String{Value: approvalToken(d.provider.approvalSecret, data.StoreID.Value, data.ItemCodes, totalCents)}
+
+	diags = resp.State.Set(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+}
@@ -4,14 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
 	"net/url"
 	"strconv"
-	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -77,20 +76,22 @@ func (d dataSourceStore) Read(ctx context.Context, req datasource.ReadRequest, r
 		return
 	}
 
-	var client = &http.Client{Timeout: 10 * time.Second}
 	address_url_obj := make(map[string]string)
 	err := json.Unmarshal([]byte(data.AddressURLObj.Value), &address_url_obj)
 	if err != nil {
-		log.Fatalf("Cannot unmarshall address_url_obj")
+		resp.Diagnostics.AddAttributeError(path.Root("address_url_object"), "Invalid address_url_object", fmt.Sprintf("The address must be the url_object of a dominos_address data source: %v", err))
+		return
 	}
 	line1 := url.QueryEscape(address_url_obj["line1"])
 	line2 := url.QueryEscape(address_url_obj["line2"])
-	stores, err := getStores(fmt.Sprintf("https://order.dominos.com/power/store-locator?s=%s&c=%s&s=Delivery", line1, line2), client)
+	stores, err := getStores(d.provider.apiContext(ctx, subsystemLocator), fmt.Sprintf("%s/power/store-locator?s=%s&c=%s&s=Delivery", d.provider.market.APIHost(), line1, line2), d.provider.client)
 	if err != nil {
-		log.Fatalf("Cannot get stores: %v", err)
+		resp.Diagnostics.AddError("Cannot find stores", err.Error())
+		return
 	}
 	if len(stores) == 0 {
-		log.Fatalf("No stores near the address %#v", address_url_obj)
+		resp.Diagnostics.AddAttributeError(path.Root("address_url_object"), "No stores found", fmt.Sprintf("Dominos has no stores delivering to %s, %s.", address_url_obj["line1"], address_url_obj["line2"]))
+		return
 	}
 	storeID, _ := strconv.ParseInt(stores[0].StoreID, 10, 64)
 	data.StoreID = types.Int64{Value: storeID}
@@ -114,17 +115,23 @@ type Store struct {
 	}
 }
 
-func getStores(url string, client *http.Client) ([]Store, error) {
-	r, err := client.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer r.Body.Close()
+func getStores(ctx context.Context, url string, client *http.Client) ([]Store, error) {
 	resp := StoresResponse{}
 
-	err = json.NewDecoder(r.Body).Decode(&resp)
+	err := getJSON(ctx, url, client, &resp)
 	if err != nil {
 		return nil, err
 	}
 	return resp.Stores, nil
 }
+
+type StoreProfile struct {
+	StoreID string
+	Phone   string
+}
+
+func getStoreProfile(ctx context.Context, url string, client *http.Client) (StoreProfile, error) {
+	resp := StoreProfile{}
+	err := getJSON(ctx, url, client, &resp)
+	return resp, err
+}
@@ -2,10 +2,12 @@ package provider
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
@@ -69,24 +71,163 @@ func (d dataSourceTracking) Read(ctx context.Context, req datasource.ReadRequest
 		return
 	}
 
-	var client = &http.Client{Timeout: 10 * time.Second}
-
-	_, err := getTrackingApiObject(fmt.Sprintf("https://trkweb.dominos.com/orderstorage/GetTrackerData?StoreID=%d&OrderKey=%d", data.StoreID.Value, data.OrderID.Value), client)
+	_, err := getTrackedOrder(d.provider.apiContext(ctx, subsystemTracker), d.provider.trackerURL(data.StoreID.Value, strconv.FormatInt(data.OrderID.Value, 10)), d.provider.client)
 	if err != nil {
-		log.Fatalf("Cannot get tracking api object: %v", err)
+		resp.Diagnostics.AddError("Cannot track order", err.Error())
+		return
 	}
 
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
 
-func getTrackingApiObject(url string, client *http.Client) (map[string]interface{}, error) {
-	r, err := client.Get(url)
+// trackerURL returns the tracker endpoint for an order.
+func (p dominosProvider) trackerURL(storeID int64, orderID string) string {
+	return fmt.Sprintf("%s/orderstorage/GetTrackerData?StoreID=%d&OrderKey=%s", p.market.TrackerHost(), storeID, url.QueryEscape(orderID))
+}
+
+// trackerPhoneURL returns the tracker endpoint listing recent orders for a phone number.
+func (p dominosProvider) trackerPhoneURL(phone string) string {
+	return fmt.Sprintf("%s/orderstorage/GetTrackerData?Phone=%s", p.market.TrackerHost(), url.QueryEscape(phone))
+}
+
+type trackedOrder struct {
+	StoreID     string
+	OrderID     string
+	Phone       string
+	OrderStatus string
+	StartTime   string
+}
+
+// trackerResponse is the SOAP envelope GetTrackerData answers with, both
+// for a single order and for the recent orders of a phone number.
+type trackerResponse struct {
+	OrderStatuses []trackedOrder `xml:"Body>GetTrackerDataResponse>OrderStatuses>OrderStatus"`
+}
+
+// getTrackedOrder returns the tracker's view of a single order. Orders the
+// tracker doesn't know about yet have no status.
+func getTrackedOrder(ctx context.Context, url string, client *http.Client) (trackedOrder, error) {
+	orders, err := getTrackedOrders(ctx, url, client)
+	if err != nil || len(orders) == 0 {
+		return trackedOrder{}, err
+	}
+	return orders[0], nil
+}
+
+// mostRecentOrder returns the order that was started last. The tracker
+// doesn't promise any order, but its StartTime sorts as a string.
+func mostRecentOrder(orders []trackedOrder) trackedOrder {
+	latest := orders[0]
+	for _, o := range orders[1:] {
+		if o.StartTime > latest.StartTime {
+			latest = o
+		}
+	}
+	return latest
+}
+
+// getTrackedOrders returns the orders a tracker response lists.
+func getTrackedOrders(ctx context.Context, url string, client *http.Client) ([]trackedOrder, error) {
+	resp := trackerResponse{}
+
+	err := getXML(ctx, url, client, &resp)
 	if err != nil {
 		return nil, err
 	}
-	defer r.Body.Close()
-	resp := make(map[string]interface{})
-	err = json.NewDecoder(r.Body).Decode(&resp)
-	return resp, err
+	return resp.OrderStatuses, nil
+}
+
+// Stages an order goes through, in order, as far as wait_until is concerned.
+const (
+	orderStageUnknown = iota
+	orderStagePlaced
+	orderStageOutForDelivery
+	orderStageDelivered
+)
+
+var orderStages = map[string]int{
+	"placed":           orderStagePlaced,
+	"out_for_delivery": orderStageOutForDelivery,
+	"delivered":        orderStageDelivered,
+}
+
+// orderStage maps a tracker status onto a stage.
+func orderStage(status string) int {
+	switch strings.ToLower(status) {
+	case "":
+		return orderStageUnknown
+	case "out the door", "being delivered", "out for delivery":
+		return orderStageOutForDelivery
+	case "complete", "delivered":
+		return orderStageDelivered
+	default:
+		// Order Placed, Prep, Bake, Quality Check, etc.
+		return orderStagePlaced
+	}
+}
+
+// waitForOrderStage polls the tracker with exponential backoff until the
+// order reaches stage or timeout passes, and returns the last status seen.
+// Errors that polling again won't fix end the wait straight away.
+func waitForOrderStage(ctx context.Context, url string, stage int, timeout time.Duration, client *http.Client) (string, error) {
+	deadline := time.Now().Add(timeout)
+	delay := 10 * time.Second
+	status := ""
+
+	for {
+		tracked, err := getTrackedOrder(ctx, url, client)
+		if err == nil {
+			status = tracked.OrderStatus
+			if orderStage(status) >= stage {
+				return status, nil
+			}
+		} else if permanentTrackerError(err) {
+			return status, fmt.Errorf("the tracker could not be read: %w", err)
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			switch {
+			case err != nil:
+				return status, fmt.Errorf("timed out after %s waiting for the order, and the last attempt to read the tracker failed: %w", timeout, err)
+			case status == "":
+				return status, fmt.Errorf("timed out after %s waiting for the order to show up in the tracker", timeout)
+			default:
+				return status, fmt.Errorf("timed out after %s waiting for the order, which was last seen as %q", timeout, status)
+			}
+		}
+
+		// Sleep for whatever is left rather than giving up early, so the
+		// last poll happens at the deadline
+		wait := delay
+		if wait > remaining {
+			wait = remaining
+		}
+		select {
+		case <-ctx.Done():
+			return status, ctx.Err()
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+		if delay > 2*time.Minute {
+			delay = 2 * time.Minute
+		}
+	}
+}
+
+// permanentTrackerError reports whether err from reading the tracker will
+// happen again however long we wait: a response that can't be decoded, or a
+// 4xx other than 429. Connection errors, 429s and 5xxs may clear up.
+func permanentTrackerError(err error) bool {
+	var decode *decodeError
+	if errors.As(err, &decode) {
+		return true
+	}
+	var status *httpStatusError
+	if errors.As(err, &status) {
+		return status.StatusCode >= 400 && status.StatusCode < 500 && status.StatusCode != http.StatusTooManyRequests
+	}
+	return false
 }
@@ -0,0 +1,152 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveFile answers every request with the file at path.
+func serveFile(t *testing.T, path string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+		http.ServeFile(w, r, path)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetTrackedOrders(t *testing.T) {
+	server := serveFile(t, "testdata/tracker_phone.xml")
+
+	orders, err := getTrackedOrders(context.Background(), server.URL+"/orderstorage/GetTrackerData?Phone=5555555555", server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []trackedOrder{
+		{StoreID: "7940", OrderID: "2026-10-16#41870", Phone: "5555555555", OrderStatus: "Complete", StartTime: "2026-10-16T18:31:30"},
+		{StoreID: "7940", OrderID: "2026-10-19#42213", Phone: "5555555555", OrderStatus: "Oven", StartTime: "2026-10-19T18:39:53"},
+	}
+	if !reflect.DeepEqual(orders, want) {
+		t.Errorf("got %+v, want %+v", orders, want)
+	}
+}
+
+func TestGetTrackedOrder(t *testing.T) {
+	server := serveFile(t, "testdata/tracker_phone.xml")
+
+	order, err := getTrackedOrder(context.Background(), server.URL+"/orderstorage/GetTrackerData?StoreID=7940&OrderKey=41870", server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order.OrderStatus != "Complete" || order.StartTime != "2026-10-16T18:31:30" {
+		t.Errorf("got %+v, want the first order in the response", order)
+	}
+}
+
+func TestGetTrackedOrderNotTrackedYet(t *testing.T) {
+	server := serveFile(t, "testdata/tracker_empty.xml")
+
+	order, err := getTrackedOrder(context.Background(), server.URL+"/orderstorage/GetTrackerData?StoreID=7940&OrderKey=42214", server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if order != (trackedOrder{}) {
+		t.Errorf("got %+v, want no order", order)
+	}
+}
+
+func TestMostRecentOrder(t *testing.T) {
+	tests := map[string]struct {
+		orders []trackedOrder
+		want   string
+	}{
+		"one order": {
+			orders: []trackedOrder{{OrderID: "a", StartTime: "2026-10-16T18:31:30"}},
+			want:   "a",
+		},
+		"oldest first": {
+			orders: []trackedOrder{{OrderID: "a", StartTime: "2026-10-16T18:31:30"}, {OrderID: "b", StartTime: "2026-10-19T18:39:53"}},
+			want:   "b",
+		},
+		"newest first": {
+			orders: []trackedOrder{{OrderID: "b", StartTime: "2026-10-19T18:39:53"}, {OrderID: "a", StartTime: "2026-10-16T18:31:30"}},
+			want:   "b",
+		},
+		"missing start time": {
+			orders: []trackedOrder{{OrderID: "a"}, {OrderID: "b", StartTime: "2026-10-16T18:31:30"}},
+			want:   "b",
+		},
+	}
+
+	for name, tt := range tests {
+		if got := mostRecentOrder(tt.orders); got.OrderID != tt.want {
+			t.Errorf("%s: got order %q, want %q", name, got.OrderID, tt.want)
+		}
+	}
+}
+
+func TestWaitForOrderStageErrors(t *testing.T) {
+	tests := map[string]struct {
+		status  int
+		body    string
+		timeout time.Duration
+		wantErr string
+	}{
+		"not found fails straight away": {
+			status:  http.StatusNotFound,
+			body:    "no such order",
+			timeout: time.Hour,
+			wantErr: "the tracker could not be read: GET",
+		},
+		"undecodable fails straight away": {
+			status:  http.StatusOK,
+			body:    "<html><body>Down for maintenance",
+			timeout: time.Hour,
+			wantErr: "the tracker could not be read: cannot decode response",
+		},
+		"server errors are waited out and reported": {
+			status:  http.StatusServiceUnavailable,
+			body:    "try later",
+			timeout: 50 * time.Millisecond,
+			wantErr: "the last attempt to read the tracker failed: GET",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			start := time.Now()
+			_, err := waitForOrderStage(context.Background(), server.URL, orderStagePlaced, tt.timeout, server.Client())
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("took %s to fail", elapsed)
+			}
+		})
+	}
+}
+
+func TestWaitForOrderStageReached(t *testing.T) {
+	server := serveFile(t, "testdata/tracker_phone.xml")
+
+	status, err := waitForOrderStage(context.Background(), server.URL, orderStageDelivered, time.Hour, server.Client())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status != "Complete" {
+		t.Errorf("got status %q, want Complete", status)
+	}
+}
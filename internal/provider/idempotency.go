@@ -0,0 +1,164 @@
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultDuplicateWindow is how long an identical order is refused for when
+// the resource doesn't set duplicate_window.
+const defaultDuplicateWindow = time.Hour
+
+// idempotencyRetention is how long order keys are kept for, regardless of
+// the duplicate window.
+const idempotencyRetention = 30 * 24 * time.Hour
+
+// defaultIdempotencyPath returns where order keys are kept when the provider
+// doesn't set idempotency_file.
+func defaultIdempotencyPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "terraform-provider-dominos", "orders.json")
+}
+
+// idempotencyKey identifies an order by what is being ordered, where to, and
+// the user's optional token, so retrying the same apply produces the same key.
+func idempotencyKey(storeID int64, addressAPIObj string, itemCodes []string, token string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d\n%s\n%s\n%s", storeID, addressAPIObj, strings.Join(itemCodes, ","), token)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// idempotencyLog is a local JSON file of recently attempted orders. An entry
+// is written before place-order is called, so an order whose outcome is
+// unknown (e.g. the request timed out) still blocks a retry.
+type idempotencyLog struct {
+	Records []idempotencyRecord `json:"records"`
+}
+
+type idempotencyRecord struct {
+	Key       string    `json:"key"`
+	StartedAt time.Time `json:"started_at"`
+	Placed    bool      `json:"placed"`
+	OrderID   string    `json:"order_id,omitempty"`
+}
+
+// readIdempotencyLog loads the log at path. A missing file is an empty log.
+func readIdempotencyLog(path string) (idempotencyLog, error) {
+	l := idempotencyLog{}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+
+	err = json.Unmarshal(b, &l)
+	if err != nil {
+		return l, fmt.Errorf("cannot parse %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// find returns the most recent record for key started within window of now.
+func (l idempotencyLog) find(key string, window time.Duration, now time.Time) (idempotencyRecord, bool) {
+	for i := len(l.Records) - 1; i >= 0; i-- {
+		rec := l.Records[i]
+		if rec.Key == key && now.Sub(rec.StartedAt) < window {
+			return rec, true
+		}
+	}
+	return idempotencyRecord{}, false
+}
+
+// duplicateOrderError explains why an order is being refused.
+func duplicateOrderError(rec idempotencyRecord) error {
+	if rec.Placed {
+		return fmt.Errorf("an identical order (%s) was already placed at %s. To order the same thing again on purpose, set a new idempotency_token", rec.OrderID, rec.StartedAt.Local().Format(time.Kitchen))
+	}
+	return fmt.Errorf("an attempt to place an identical order at %s didn't finish, so it may have gone through. Check your email or the tracker before ordering again, and set a new idempotency_token if it didn't", rec.StartedAt.Local().Format(time.Kitchen))
+}
+
+// idempotencyMu serialises updates to the idempotency log. Terraform creates
+// resources in parallel, so without it two identical orders (e.g. count = 2)
+// could both find no record and both be placed.
+var idempotencyMu sync.Mutex
+
+// updateIdempotencyLog applies f to the log at path and writes it back,
+// dropping records past the retention period.
+func updateIdempotencyLog(path string, f func(l *idempotencyLog)) error {
+	idempotencyMu.Lock()
+	defer idempotencyMu.Unlock()
+
+	l, err := readIdempotencyLog(path)
+	if err != nil {
+		return err
+	}
+	f(&l)
+
+	kept := l.Records[:0]
+	for _, rec := range l.Records {
+		if time.Since(rec.StartedAt) < idempotencyRetention {
+			kept = append(kept, rec)
+		}
+	}
+	l.Records = kept
+
+	return writeJSONFile(path, l)
+}
+
+// beginIdempotentOrder records an attempt to place the order with key, unless
+// an identical order was already attempted within window.
+func beginIdempotentOrder(path, key string, window time.Duration, now time.Time) error {
+	var duplicate error
+	err := updateIdempotencyLog(path, func(l *idempotencyLog) {
+		if rec, ok := l.find(key, window, now); ok {
+			duplicate = duplicateOrderError(rec)
+			return
+		}
+		l.Records = append(l.Records, idempotencyRecord{Key: key, StartedAt: now})
+	})
+	if duplicate != nil {
+		return duplicate
+	}
+	return err
+}
+
+// finishIdempotentOrder marks an attempt as successfully placed.
+func finishIdempotentOrder(path, key string, startedAt time.Time, orderID string) error {
+	return updateIdempotencyLog(path, func(l *idempotencyLog) {
+		for i := range l.Records {
+			if l.Records[i].Key == key && l.Records[i].StartedAt.Equal(startedAt) {
+				l.Records[i].Placed = true
+				l.Records[i].OrderID = orderID
+			}
+		}
+	})
+}
+
+// abandonIdempotentOrder forgets an attempt that Dominos refused, so that it
+// can be retried.
+func abandonIdempotentOrder(path, key string, startedAt time.Time) error {
+	return updateIdempotencyLog(path, func(l *idempotencyLog) {
+		kept := l.Records[:0]
+		for _, rec := range l.Records {
+			if rec.Key != key || !rec.StartedAt.Equal(startedAt) {
+				kept = append(kept, rec)
+			}
+		}
+		l.Records = kept
+	})
+}
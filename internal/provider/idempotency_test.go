@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	base := idempotencyKey(7940, `{"Street":"1 Main St"}`, []string{"14SCREEN", "20BCOKE"}, "")
+
+	if again := idempotencyKey(7940, `{"Street":"1 Main St"}`, []string{"14SCREEN", "20BCOKE"}, ""); again != base {
+		t.Errorf("the same order has different keys: %s and %s", base, again)
+	}
+
+	tests := map[string]string{
+		"store":       idempotencyKey(7941, `{"Street":"1 Main St"}`, []string{"14SCREEN", "20BCOKE"}, ""),
+		"address":     idempotencyKey(7940, `{"Street":"2 Main St"}`, []string{"14SCREEN", "20BCOKE"}, ""),
+		"items":       idempotencyKey(7940, `{"Street":"1 Main St"}`, []string{"14SCREEN"}, ""),
+		"item order":  idempotencyKey(7940, `{"Street":"1 Main St"}`, []string{"20BCOKE", "14SCREEN"}, ""),
+		"token":       idempotencyKey(7940, `{"Street":"1 Main St"}`, []string{"14SCREEN", "20BCOKE"}, "friday"),
+		"joined code": idempotencyKey(7940, `{"Street":"1 Main St"}`, []string{"14SCREEN,20BCOKE"}, "x"),
+	}
+	for name, key := range tests {
+		if key == base {
+			t.Errorf("changing the %s doesn't change the key", name)
+		}
+	}
+}
+
+func TestIdempotencyLogFind(t *testing.T) {
+	now := time.Date(2026, time.October, 19, 18, 0, 0, 0, time.UTC)
+	l := idempotencyLog{Records: []idempotencyRecord{
+		{Key: "a", StartedAt: now.Add(-20 * time.Minute), Placed: true, OrderID: "old"},
+		{Key: "a", StartedAt: now.Add(-5 * time.Minute), Placed: true, OrderID: "new"},
+		{Key: "b", StartedAt: now.Add(-time.Hour)},
+	}}
+
+	if rec, ok := l.find("a", 10*time.Minute, now); !ok || rec.OrderID != "new" {
+		t.Errorf("find(a) = %+v, %v, want the most recent record", rec, ok)
+	}
+	if _, ok := l.find("b", 10*time.Minute, now); ok {
+		t.Error("found a record outside the window")
+	}
+	if _, ok := l.find("c", time.Hour, now); ok {
+		t.Error("found a record for a key that was never logged")
+	}
+}
+
+func TestBeginIdempotentOrderParallel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.json")
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	begun := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if beginIdempotentOrder(path, "same-order", time.Hour, now) == nil {
+				mu.Lock()
+				begun++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if begun != 1 {
+		t.Errorf("%d identical orders were begun, want 1", begun)
+	}
+}
+
+func TestIdempotentOrderLifecycle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orders.json")
+	now := time.Now()
+
+	if err := beginIdempotentOrder(path, "a", time.Hour, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := abandonIdempotentOrder(path, "a", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := beginIdempotentOrder(path, "a", time.Hour, now); err != nil {
+		t.Fatalf("an abandoned order can't be retried: %v", err)
+	}
+	if err := finishIdempotentOrder(path, "a", now, "order-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := readIdempotencyLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec, ok := l.find("a", time.Hour, now); !ok || !rec.Placed || rec.OrderID != "order-1" {
+		t.Errorf("got record %+v, %v, want a placed order-1", rec, ok)
+	}
+	if err := beginIdempotentOrder(path, "a", time.Hour, now); err == nil {
+		t.Error("a placed order could be begun again")
+	}
+}
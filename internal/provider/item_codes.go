@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// maxSuggestions is how many similar codes are suggested for an unknown one.
+const maxSuggestions = 3
+
+// unavailableMenuCodes returns the item codes on a menu that the store isn't
+// selling right now. Dominos lists them by product under UnsupportedProducts,
+// so every variant of an unsupported product is unavailable.
+func unavailableMenuCodes(body []byte) (map[string]bool, error) {
+	var menu struct {
+		Variants map[string]struct {
+			ProductCode string
+		}
+		UnsupportedProducts map[string]interface{}
+	}
+	err := json.Unmarshal(body, &menu)
+	if err != nil {
+		return nil, err
+	}
+
+	unavailable := map[string]bool{}
+	for code, variant := range menu.Variants {
+		_, product := menu.UnsupportedProducts[variant.ProductCode]
+		_, self := menu.UnsupportedProducts[code]
+		if product || self {
+			unavailable[code] = true
+		}
+	}
+	return unavailable, nil
+}
+
+// checkItemCodes looks every item code up on the store's menu, or menuFile
+// if it is set, returning a diagnostic on each one that isn't on it or can't
+// be ordered right now.
+func (p dominosProvider) checkItemCodes(ctx context.Context, storeID int64, menuFile types.String, itemCodes []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	menuPath := path.Root("store_id")
+	if !menuFile.Null {
+		menuPath = path.Root("menu_file")
+	}
+
+	body, err := p.orderMenuBody(ctx, storeID, menuFile)
+	if err != nil {
+		diags.AddAttributeError(menuPath, "Cannot get menu", fmt.Sprintf("The menu could not be fetched to check item_codes: %v", err))
+		return diags
+	}
+	items, err := parseMenuItems(body)
+	if err != nil {
+		diags.AddAttributeError(menuPath, "Cannot get menu", fmt.Sprintf("The menu could not be read to check item_codes: %v", err))
+		return diags
+	}
+	unavailable, err := unavailableMenuCodes(body)
+	if err != nil {
+		diags.AddAttributeError(menuPath, "Cannot get menu", fmt.Sprintf("The menu could not be read to check item_codes: %v", err))
+		return diags
+	}
+
+	onMenu := make(map[string]bool, len(items))
+	codes := make([]string, 0, len(items))
+	for i := range items {
+		onMenu[items[i].Code] = true
+		if !unavailable[items[i].Code] {
+			codes = append(codes, items[i].Code)
+		}
+	}
+
+	for i, code := range itemCodes {
+		at := path.Root("item_codes").AtListIndex(i)
+
+		if unavailable[code] {
+			diags.AddAttributeError(at, "Item unavailable", fmt.Sprintf("Item %q is on the menu for store %d, but the store isn't selling it right now.", code, storeID))
+			continue
+		}
+		if onMenu[code] {
+			continue
+		}
+
+		detail := fmt.Sprintf("Item %q is not on the menu for store %d.", code, storeID)
+		if suggestions := closestCodes(code, codes, maxSuggestions); len(suggestions) > 0 {
+			quoted := make([]string, 0, len(suggestions))
+			for _, s := range suggestions {
+				quoted = append(quoted, fmt.Sprintf("%q", s))
+			}
+			detail += fmt.Sprintf(" Did you mean %s?", strings.Join(quoted, ", "))
+		}
+		diags.AddAttributeError(at, "Unknown item code", detail)
+	}
+	return diags
+}
+
+// closestCodes returns up to n of codes closest to code by edit distance,
+// ignoring case. Codes too different to be a typo aren't suggested.
+func closestCodes(code string, codes []string, n int) []string {
+	type match struct {
+		code     string
+		distance int
+	}
+
+	limit := len(code)/3 + 1
+	matches := []match{}
+	for _, c := range codes {
+		d := editDistance(strings.ToUpper(code), strings.ToUpper(c))
+		if d <= limit {
+			matches = append(matches, match{code: c, distance: d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].code < matches[j].code
+	})
+
+	suggestions := []string{}
+	for i := 0; i < len(matches) && i < n; i++ {
+		suggestions = append(suggestions, matches[i].code)
+	}
+	return suggestions
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(br)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "ABC", 3},
+		{"ABC", "", 3},
+		{"14SCREEN", "14SCREEN", 0},
+		{"14SCREN", "14SCREEN", 1},
+		{"14SCREEN", "12SCREEN", 1},
+		{"14SCREEN", "41SCREEN", 2},
+		{"KITTEN", "SITTING", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClosestCodes(t *testing.T) {
+	codes := []string{"10SCREEN", "12SCREEN", "14SCREEN", "16SCREEN", "20BCOKE", "B8PCPT", "W08PHOTW"}
+
+	tests := []struct {
+		code string
+		n    int
+		want []string
+	}{
+		{code: "14SCREN", n: 3, want: []string{"14SCREEN", "10SCREEN", "12SCREEN"}},
+		{code: "14screen", n: 3, want: []string{"14SCREEN", "10SCREEN", "12SCREEN"}},
+		{code: "14SCREEN", n: 1, want: []string{"14SCREEN"}},
+		{code: "20BCOK", n: 3, want: []string{"20BCOKE"}},
+		{code: "PEPSI", n: 3, want: []string{}},
+		{code: "14SCREN", n: 0, want: []string{}},
+	}
+	for _, tt := range tests {
+		if got := closestCodes(tt.code, codes, tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("closestCodes(%q, %d) = %q, want %q", tt.code, tt.n, got, tt.want)
+		}
+	}
+}
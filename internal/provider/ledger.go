@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// budgetConfig is the provider's budget block, converted to cents.
+type budgetConfig struct {
+	amountCents int64
+	period      string
+	ledgerPath  string
+}
+
+// ledger is a local JSON file recording every order placed by the provider,
+// used to keep track of spending against the budget.
+type ledger struct {
+	Entries []ledgerEntry `json:"entries"`
+}
+
+type ledgerEntry struct {
+	PlacedAt   time.Time `json:"placed_at"`
+	StoreID    int64     `json:"store_id"`
+	OrderID    string    `json:"order_id"`
+	TotalCents int64     `json:"total_cents"`
+	Currency   string    `json:"currency"`
+}
+
+// readLedger loads the ledger at path. A missing file is an empty ledger.
+func readLedger(path string) (ledger, error) {
+	l := ledger{}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+
+	err = json.Unmarshal(b, &l)
+	if err != nil {
+		return l, fmt.Errorf("cannot parse ledger %s: %w", path, err)
+	}
+	return l, nil
+}
+
+// appendLedger adds an entry to the ledger at path.
+func appendLedger(path string, entry ledgerEntry) error {
+	l, err := readLedger(path)
+	if err != nil {
+		return err
+	}
+	l.Entries = append(l.Entries, entry)
+
+	return writeJSONFile(path, l)
+}
+
+// ledgerMu serialises orders that spend from the budget. Terraform creates
+// resources in parallel, so without it two orders could both pass the same
+// remaining budget.
+var ledgerMu sync.Mutex
+
+// lockLedger locks the ledger and returns a function that unlocks it.
+// Unlocking twice is safe, so it can be deferred and also called as soon as
+// the ledger is up to date.
+func lockLedger() func() {
+	ledgerMu.Lock()
+	var once sync.Once
+	return func() { once.Do(ledgerMu.Unlock) }
+}
+
+// writeJSONFile replaces the file at path with v encoded as JSON. The file is
+// written to a temporary file next to path first and renamed into place, so
+// readers never see a half written file.
+func writeJSONFile(path string, v interface{}) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// Each write gets its own temporary file, so parallel writers can't
+	// rename each other's half written file into place
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err == nil {
+		err = tmp.Chmod(0o644)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// spentSince totals every entry placed at or after start.
+func (l ledger) spentSince(start time.Time) int64 {
+	var total int64
+	for _, e := range l.Entries {
+		if !e.PlacedAt.Before(start) {
+			total += e.TotalCents
+		}
+	}
+	return total
+}
+
+// budgetRemaining returns how much of the budget is left for the period
+// containing now. It is negative once the budget has been overspent.
+func (p dominosProvider) budgetRemaining(now time.Time) (int64, error) {
+	l, err := readLedger(p.budget.ledgerPath)
+	if err != nil {
+		return 0, err
+	}
+	return p.budget.amountCents - l.spentSince(periodStart(now, p.budget.period)), nil
+}
+
+// periodStart returns the start of the budget period containing now. Weeks
+// start on Monday, months on the 1st, both at midnight local time.
+func periodStart(now time.Time, period string) time.Time {
+	y, m, d := now.Date()
+	switch period {
+	case "weekly":
+		offset := (int(now.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, now.Location())
+	default:
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location())
+	}
+}
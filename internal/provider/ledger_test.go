@@ -0,0 +1,117 @@
+package provider
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPeriodStart(t *testing.T) {
+	tests := []struct {
+		now    time.Time
+		period string
+		want   time.Time
+	}{
+		{
+			now:    time.Date(2026, time.October, 19, 18, 30, 0, 0, time.UTC),
+			period: "monthly",
+			want:   time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			now:    time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC),
+			period: "monthly",
+			want:   time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// A Monday
+			now:    time.Date(2026, time.October, 19, 18, 30, 0, 0, time.UTC),
+			period: "weekly",
+			want:   time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// A Sunday, in the week that started the previous Monday
+			now:    time.Date(2026, time.October, 25, 23, 59, 0, 0, time.UTC),
+			period: "weekly",
+			want:   time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// Weeks can start in the previous month
+			now:    time.Date(2026, time.November, 1, 12, 0, 0, 0, time.UTC),
+			period: "weekly",
+			want:   time.Date(2026, time.October, 26, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		if got := periodStart(tt.now, tt.period); !got.Equal(tt.want) {
+			t.Errorf("periodStart(%s, %q) = %s, want %s", tt.now, tt.period, got, tt.want)
+		}
+	}
+}
+
+func TestSpentSince(t *testing.T) {
+	start := time.Date(2026, time.October, 1, 0, 0, 0, 0, time.UTC)
+	l := ledger{Entries: []ledgerEntry{
+		{PlacedAt: start.Add(-time.Second), TotalCents: 1000},
+		{PlacedAt: start, TotalCents: 1299},
+		{PlacedAt: start.Add(48 * time.Hour), TotalCents: 2501},
+	}}
+
+	if got := l.spentSince(start); got != 3800 {
+		t.Errorf("spentSince = %d, want 3800", got)
+	}
+	if got := (ledger{}).spentSince(start); got != 0 {
+		t.Errorf("spentSince on an empty ledger = %d, want 0", got)
+	}
+}
+
+func TestAppendLedgerParallel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ledger.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			unlock := lockLedger()
+			defer unlock()
+			if err := appendLedger(path, ledgerEntry{PlacedAt: time.Now(), TotalCents: int64(i)}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	l, err := readLedger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(l.Entries) != 20 {
+		t.Errorf("ledger has %d entries, want 20", len(l.Entries))
+	}
+}
+
+func TestWriteJSONFileParallel(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file.json")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := writeJSONFile(path, ledger{Entries: make([]ledgerEntry, i)}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, err := readLedger(path); err != nil {
+		t.Errorf("the file was left half written: %v", err)
+	}
+	if leftover, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(leftover) > 0 {
+		t.Errorf("temporary files were left behind: %v", leftover)
+	}
+}
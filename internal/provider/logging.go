@@ -0,0 +1,162 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Log subsystems, one per area of the Dominos API. Enable them one at a time
+// with TF_LOG_PROVIDER_DOMINOS_<NAME>, e.g. TF_LOG_PROVIDER_DOMINOS_MENU=DEBUG.
+const (
+	subsystemLocator = "locator"
+	subsystemLoyalty = "loyalty"
+	subsystemMenu    = "menu"
+	subsystemOrder   = "order"
+	subsystemTracker = "tracker"
+)
+
+type subsystemKey struct{}
+
+// apiContext returns a context for making requests to one area of the API.
+// Requests made with it are logged to that subsystem, with the customer's
+// contact details, card numbers and rewards login masked, so debug logs are
+// safe to share.
+func (p dominosProvider) apiContext(ctx context.Context, subsystem string) context.Context {
+	ctx = tflog.NewSubsystem(ctx, subsystem, tflog.WithLevelFromEnv("TF_LOG_PROVIDER_DOMINOS", subsystem))
+
+	secrets := p.logSecrets()
+	ctx = tflog.MaskLogStrings(ctx, secrets...)
+	ctx = tflog.SubsystemMaskLogStrings(ctx, subsystem, secrets...)
+
+	return context.WithValue(ctx, subsystemKey{}, subsystem)
+}
+
+// maskLogValues masks values in logs from ctx, on top of the provider's own
+// secrets, e.g. a phone number that is only given to one request.
+func maskLogValues(ctx context.Context, values ...string) context.Context {
+	secrets := longSecrets(values...)
+	ctx = tflog.MaskLogStrings(ctx, secrets...)
+	if subsystem, ok := ctx.Value(subsystemKey{}).(string); ok {
+		ctx = tflog.SubsystemMaskLogStrings(ctx, subsystem, secrets...)
+	}
+	return ctx
+}
+
+// minSecretLength is the shortest value masked in logs and cassettes. Both
+// work by substring, so anything shorter would also hide store IDs, prices
+// and URL paths that happen to contain it.
+const minSecretLength = 6
+
+// logSecrets are the values from the provider block that could show up in a
+// logged URL or error, as they are and as they appear in a URL. Names and
+// CVVs are left out: they are only ever sent in request bodies, which aren't
+// logged, and are short enough to turn up in unrelated output.
+func (p dominosProvider) logSecrets() []string {
+	values := []string{p.emailAddr, p.phoneNumber}
+	values = append(values, p.paymentNumbers()...)
+	if p.loyalty != nil {
+		values = append(values, p.loyalty.username, p.loyalty.password)
+	}
+
+	return longSecrets(values...)
+}
+
+// paymentNumbers are the numbers of every credit and gift card configured on
+// the provider.
+func (p dominosProvider) paymentNumbers() []string {
+	numbers := []string{}
+	for _, card := range p.cards() {
+		numbers = append(numbers, strconv.FormatInt(card.CreditCardNumber.Value, 10))
+	}
+	for _, profile := range p.paymentProfiles {
+		if profile.GiftCard != nil {
+			numbers = append(numbers, profile.GiftCard.Number.Value)
+		}
+	}
+	return numbers
+}
+
+// cards returns every credit card configured on the provider.
+func (p dominosProvider) cards() []*creditCardData {
+	cards := []*creditCardData{}
+	if p.creditCard != nil {
+		cards = append(cards, p.creditCard)
+	}
+	for _, profile := range p.paymentProfiles {
+		if profile.Card != nil {
+			cards = append(cards, profile.Card)
+		}
+	}
+	return cards
+}
+
+// longSecrets returns the variants of values that are at least
+// minSecretLength long.
+func longSecrets(values ...string) []string {
+	secrets := []string{}
+	for _, secret := range secretVariants(values...) {
+		if len(secret) >= minSecretLength {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+func secretVariants(values ...string) []string {
+	secrets := make([]string, 0, 2*len(values))
+	for _, v := range values {
+		// Masking an empty string would mask everything
+		if v == "" {
+			continue
+		}
+		secrets = append(secrets, v)
+		if escaped := url.QueryEscape(v); escaped != v {
+			secrets = append(secrets, escaped)
+		}
+	}
+	return secrets
+}
+
+// logDebug logs to the subsystem ctx was made for by apiContext, or to the
+// provider's own logger otherwise.
+func logDebug(ctx context.Context, msg string, fields map[string]interface{}) {
+	if subsystem, ok := ctx.Value(subsystemKey{}).(string); ok {
+		tflog.SubsystemDebug(ctx, subsystem, msg, fields)
+		return
+	}
+	tflog.Debug(ctx, msg, fields)
+}
+
+// loggingTransport logs every request sent, including each retry, with how
+// long it took and the status code it got back.
+type loggingTransport struct {
+	base http.RoundTripper
+}
+
+func (t loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	start := time.Now()
+
+	resp, err := t.base.RoundTrip(req)
+
+	fields := map[string]interface{}{
+		"http_method":    req.Method,
+		"http_url":       req.URL.Redacted(),
+		"duration_ms":    time.Since(start).Milliseconds(),
+		"http_retryable": retryable(req),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+		logDebug(ctx, "Dominos API request failed", fields)
+		return nil, err
+	}
+
+	fields["http_status"] = resp.StatusCode
+	logDebug(ctx, "Dominos API request", fields)
+	return resp, nil
+}
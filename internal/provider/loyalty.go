@@ -0,0 +1,290 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// loyaltyClientID and loyaltyScope are what the Dominos website logs in with.
+const (
+	loyaltyClientID = "nolo-rm"
+	loyaltyScope    = "customer:profile:read:basic customer:loyalty:read order:place:cardOnFile"
+)
+
+// orderHistoryLimit is how many recent orders are searched when importing.
+const orderHistoryLimit = 50
+
+type loyaltyData struct {
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+}
+
+// loyaltySession is a login to a Dominos rewards account. It logs in the
+// first time it is needed, so configuring the provider never touches the
+// network, and is shared by every Resource and DataSource.
+type loyaltySession struct {
+	username string
+	password string
+
+	mu         sync.Mutex
+	token      string
+	customerID string
+}
+
+type loyaltyAccount struct {
+	VestedPointBalance  json.Number
+	PendingPointBalance json.Number
+	LoyaltyCoupons      []loyaltyCoupon
+}
+
+// loyaltyCoupon is a reward the account can exchange points for.
+type loyaltyCoupon struct {
+	CouponCode string
+	PointValue json.Number
+}
+
+type bearerTokenKey struct{}
+
+// withBearerToken makes requests sent with ctx as the logged in customer.
+func withBearerToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, bearerTokenKey{}, token)
+}
+
+// authorize adds the customer's token to req, if it was made with a context
+// from withBearerToken.
+func authorize(req *http.Request) {
+	if token, ok := req.Context().Value(bearerTokenKey{}).(string); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+// login returns a context that sends requests as the logged in customer, and
+// the customer's ID, logging in if it hasn't already.
+func (p dominosProvider) login(ctx context.Context) (context.Context, string, error) {
+	s := p.loyalty
+	if s == nil {
+		return ctx, "", fmt.Errorf("the provider has no loyalty block to log in with")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token == "" {
+		token := struct {
+			AccessToken string `json:"access_token"`
+		}{}
+		err := postForm(ctx, p.market.AuthHost()+"/auth-proxy-service/login", url.Values{
+			"grant_type":   {"password"},
+			"validator_id": {"VoldemortCredValidator"},
+			"client_id":    {loyaltyClientID},
+			"scope":        {loyaltyScope},
+			"username":     {s.username},
+			"password":     {s.password},
+		}, p.client, &token)
+		if err != nil {
+			return ctx, "", fmt.Errorf("cannot log in as %s: %w", s.username, err)
+		}
+
+		customer := struct {
+			CustomerID string
+		}{}
+		err = postForm(withBearerToken(ctx, token.AccessToken), p.market.APIHost()+"/power/login", url.Values{
+			"u":               {s.username},
+			"p":               {s.password},
+			"loyaltyIsActive": {"true"},
+		}, p.client, &customer)
+		if err != nil {
+			return ctx, "", fmt.Errorf("cannot log in as %s: %w", s.username, err)
+		}
+		if customer.CustomerID == "" {
+			return ctx, "", fmt.Errorf("cannot log in as %s: Dominos didn't return a customer ID", s.username)
+		}
+
+		s.token = token.AccessToken
+		s.customerID = customer.CustomerID
+	}
+	return withBearerToken(ctx, s.token), s.customerID, nil
+}
+
+// loyaltyAccount returns the points and rewards of the logged in customer.
+func (p dominosProvider) loyaltyAccount(ctx context.Context) (loyaltyAccount, error) {
+	ctx, customerID, err := p.login(ctx)
+	if err != nil {
+		return loyaltyAccount{}, err
+	}
+	return p.getLoyaltyAccount(ctx, customerID)
+}
+
+func (p dominosProvider) getLoyaltyAccount(ctx context.Context, customerID string) (loyaltyAccount, error) {
+	account := loyaltyAccount{}
+	err := getJSON(ctx, fmt.Sprintf("%s/power/customer/%s/loyalty", p.market.APIHost(), url.PathEscape(customerID)), p.client, &account)
+	return account, err
+}
+
+// reward finds the reward with the given coupon code, and checks the account
+// has the points for it.
+func (a loyaltyAccount) reward(code string) (loyaltyCoupon, error) {
+	codes := make([]string, 0, len(a.LoyaltyCoupons))
+	for _, coupon := range a.LoyaltyCoupons {
+		if coupon.CouponCode != code {
+			codes = append(codes, fmt.Sprintf("%q", coupon.CouponCode))
+			continue
+		}
+		cost, err := points("the cost of reward "+strconv.Quote(code), coupon.PointValue)
+		if err != nil {
+			return coupon, err
+		}
+		balance, err := points("the points balance", a.VestedPointBalance)
+		if err != nil {
+			return coupon, err
+		}
+		if balance < cost {
+			return coupon, fmt.Errorf("reward %q costs %d points, but the account only has %d", code, cost, balance)
+		}
+		return coupon, nil
+	}
+	if len(codes) == 0 {
+		return loyaltyCoupon{}, fmt.Errorf("reward %q doesn't exist, the account has no rewards", code)
+	}
+	return loyaltyCoupon{}, fmt.Errorf("reward %q doesn't exist, it must be one of %s", code, strings.Join(codes, ", "))
+}
+
+// points parses a number of points. A missing number is 0.
+func points(what string, n json.Number) (int64, error) {
+	if n == "" {
+		return 0, nil
+	}
+	v, err := n.Int64()
+	if err != nil {
+		return 0, fmt.Errorf("%s is %q, which is not a whole number of points", what, n)
+	}
+	return v, nil
+}
+
+// redeemReward adds a reward to o, as the logged in customer. Orders with a
+// reward must be sent with the returned context.
+func (p dominosProvider) redeemReward(ctx context.Context, o *order, code string) (context.Context, error) {
+	ctx, customerID, err := p.login(ctx)
+	if err != nil {
+		return ctx, err
+	}
+
+	account, err := p.getLoyaltyAccount(ctx, customerID)
+	if err != nil {
+		return ctx, err
+	}
+	if _, err := account.reward(code); err != nil {
+		return ctx, err
+	}
+
+	o.CustomerID = customerID
+	o.Coupons = append(o.Coupons, orderCoupon{
+		Code:  code,
+		Qty:   1,
+		ID:    len(o.Coupons) + 1,
+		IsNew: true,
+	})
+	return ctx, nil
+}
+
+// pastOrder is an order from the logged in customer's order history.
+type pastOrder struct {
+	StoreID      string `json:"storeID"`
+	StoreOrderID string `json:"storeOrderID"`
+	Order        struct {
+		OrderID      string
+		StoreOrderID string
+		Products     []struct {
+			Code string
+			Qty  int
+		}
+		Amounts struct {
+			Customer float64
+		}
+	} `json:"order"`
+}
+
+// itemCodes lists the order's products the way item_codes does, one code per item.
+func (o pastOrder) itemCodes() []string {
+	codes := []string{}
+	for _, product := range o.Order.Products {
+		qty := product.Qty
+		if qty < 1 {
+			qty = 1
+		}
+		for i := 0; i < qty; i++ {
+			codes = append(codes, product.Code)
+		}
+	}
+	return codes
+}
+
+// findPastOrder looks for an order in the logged in customer's recent order
+// history. The order ID can be either the ID the website gives an order, or
+// the store's own order number.
+func (p dominosProvider) findPastOrder(ctx context.Context, storeID int64, orderID string) (pastOrder, bool, error) {
+	ctx, customerID, err := p.login(ctx)
+	if err != nil {
+		return pastOrder{}, false, err
+	}
+
+	history := struct {
+		CustomerOrders []pastOrder `json:"customerOrders"`
+	}{}
+	err = getJSON(ctx, fmt.Sprintf("%s/power/customer/%s/order?limit=%d&lang=%s", p.market.APIHost(), url.PathEscape(customerID), orderHistoryLimit, url.QueryEscape(p.language)), p.client, &history)
+	if err != nil {
+		return pastOrder{}, false, err
+	}
+
+	store := strconv.FormatInt(storeID, 10)
+	for _, o := range history.CustomerOrders {
+		if o.StoreID != store {
+			continue
+		}
+		// Store order IDs are sometimes prefixed with the date, e.g. '2022-08-19#12345'
+		storeOrderID := o.StoreOrderID[strings.LastIndex(o.StoreOrderID, "#")+1:]
+		if orderID == o.Order.OrderID || orderID == o.Order.StoreOrderID || orderID == o.StoreOrderID || orderID == storeOrderID {
+			return o, true, nil
+		}
+	}
+	return pastOrder{}, false, nil
+}
+
+// postForm posts a form to endpoint and decodes the JSON response into v. Logging
+// in is safe to retry.
+func postForm(ctx context.Context, endpoint string, form url.Values, client *http.Client, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	authorize(req)
+
+	r, err := client.Do(withRetries(req))
+	if err != nil {
+		return err
+	}
+	defer r.Body.Close()
+
+	if r.StatusCode == http.StatusUnauthorized || r.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("the username or password is wrong")
+	}
+	err = checkResponse(r)
+	if err != nil {
+		return err
+	}
+
+	err = json.NewDecoder(r.Body).Decode(v)
+	if err != nil {
+		return &decodeError{URL: r.Request.URL.Redacted(), Err: err}
+	}
+	return nil
+}
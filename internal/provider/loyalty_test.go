@@ -0,0 +1,79 @@
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestLoyaltyAccountReward(t *testing.T) {
+	account := loyaltyAccount{
+		VestedPointBalance: "75",
+		LoyaltyCoupons: []loyaltyCoupon{
+			{CouponCode: "8155", PointValue: "60"},
+			{CouponCode: "8156", PointValue: "120"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		account loyaltyAccount
+		code    string
+		wantErr string
+	}{
+		{name: "enough points", account: account, code: "8155"},
+		{name: "not enough points", account: account, code: "8156", wantErr: "costs 120 points, but the account only has 75"},
+		{name: "unknown reward", account: account, code: "9999", wantErr: `it must be one of "8155", "8156"`},
+		{name: "no rewards", account: loyaltyAccount{VestedPointBalance: "75"}, code: "8155", wantErr: "the account has no rewards"},
+		{
+			name:    "fractional cost",
+			account: loyaltyAccount{VestedPointBalance: "75", LoyaltyCoupons: []loyaltyCoupon{{CouponCode: "8155", PointValue: "60.5"}}},
+			code:    "8155",
+			wantErr: `"60.5", which is not a whole number`,
+		},
+		{
+			name:    "fractional balance",
+			account: loyaltyAccount{VestedPointBalance: "120.0", LoyaltyCoupons: []loyaltyCoupon{{CouponCode: "8155", PointValue: "60"}}},
+			code:    "8155",
+			wantErr: `"120.0", which is not a whole number`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			coupon, err := tt.account.reward(tt.code)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if coupon.CouponCode != tt.code {
+					t.Errorf("got reward %q, want %q", coupon.CouponCode, tt.code)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPoints(t *testing.T) {
+	tests := []struct {
+		n       json.Number
+		want    int64
+		wantErr bool
+	}{
+		{n: "", want: 0},
+		{n: "0", want: 0},
+		{n: "120", want: 120},
+		{n: "120.0", wantErr: true},
+		{n: "1e3", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := points("the points balance", tt.n)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("points(%q) = %d, %v, want %d, error %v", tt.n, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultMarket is used when the provider block does not set a market.
+const defaultMarket = "US"
+
+// market describes a country that Dominos operates an online ordering API in.
+// Each market has its own API host, address format, currency and language, so
+// anything that talks to Dominos should go through the configured market
+// instead of hardcoding a host.
+//
+// Markets outside of North America (e.g. UK or Australia) run a different
+// storefront, and can be supported by implementing this interface and adding
+// them with registerMarket.
+type market interface {
+	// Code is the value of the provider's market attribute. Ex: 'US'.
+	Code() string
+
+	// APIHost is the base URL of the ordering API, without a trailing slash.
+	APIHost() string
+
+	// TrackerHost is the base URL of the order tracker, without a trailing slash.
+	TrackerHost() string
+
+	// AuthHost is the base URL customers log in to, without a trailing slash.
+	AuthHost() string
+
+	// Currency is the ISO 4217 code that prices are returned in.
+	Currency() string
+
+	// Language is the default language code used for menus and orders.
+	Language() string
+
+	// AddressLines formats an address into the line1 & line2 the store locator expects.
+	AddressLines(street, city, region, postalCode string) (string, string)
+
+	// NormalizePhone returns a phone number in the format the market's API
+	// expects, or an error saying what is wrong with it.
+	NormalizePhone(phone string) (string, error)
+
+	// NormalizePostalCode returns a postal code in the market's canonical
+	// form, or an error if it isn't one of the market's postal codes.
+	NormalizePostalCode(postalCode string) (string, error)
+
+	// NormalizeRegion returns a region code in upper case, or an error if it
+	// isn't one of the market's states or provinces.
+	NormalizeRegion(region string) (string, error)
+}
+
+var markets = map[string]market{}
+
+func registerMarket(m market) {
+	markets[m.Code()] = m
+}
+
+func init() {
+	registerMarket(northAmericanMarket{
+		code:              "US",
+		apiHost:           "https://order.dominos.com",
+		trackerHost:       "https://trkweb.dominos.com",
+		authHost:          "https://authproxy.dominos.com",
+		currency:          "USD",
+		postalCode:        regexp.MustCompile(`^[0-9]{5}(-[0-9]{4})?$`),
+		postalCodeExample: "98101",
+		regionName:        "state",
+		regions: regionSet(
+			"AL", "AK", "AZ", "AR", "CA", "CO", "CT", "DE", "DC", "FL", "GA", "HI", "ID", "IL", "IN", "IA", "KS",
+			"KY", "LA", "ME", "MD", "MA", "MI", "MN", "MS", "MO", "MT", "NE", "NV", "NH", "NJ", "NM", "NY", "NC",
+			"ND", "OH", "OK", "OR", "PA", "PR", "RI", "SC", "SD", "TN", "TX", "UT", "VT", "VA", "WA", "WV", "WI", "WY",
+		),
+	})
+	registerMarket(northAmericanMarket{
+		code:              "CA",
+		apiHost:           "https://order.dominos.ca",
+		trackerHost:       "https://trkweb.dominos.ca",
+		authHost:          "https://authproxy.dominos.ca",
+		currency:          "CAD",
+		postalCode:        regexp.MustCompile(`^[A-Z][0-9][A-Z][0-9][A-Z][0-9]$`),
+		postalCodeExample: "A1A1A1",
+		regionName:        "province or territory",
+		regions:           regionSet("AB", "BC", "MB", "NB", "NL", "NS", "NT", "NU", "ON", "PE", "QC", "SK", "YT"),
+	})
+}
+
+// lookupMarket returns the registered market for the given code, ignoring case.
+func lookupMarket(code string) (market, error) {
+	m, ok := markets[strings.ToUpper(code)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported market %q, must be one of: %s", code, strings.Join(marketCodes(), ", "))
+	}
+	return m, nil
+}
+
+func marketCodes() []string {
+	codes := make([]string, 0, len(markets))
+	for code := range markets {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// northAmericanMarket covers the US and Canada, which share the same API and
+// "City, Region Postal" address format and only differ in host and currency.
+type northAmericanMarket struct {
+	code        string
+	apiHost     string
+	trackerHost string
+	authHost    string
+	currency    string
+
+	// postalCode matches a postal code once it has been upper cased and had
+	// its spaces removed.
+	postalCode        *regexp.Regexp
+	postalCodeExample string
+
+	regionName string
+	regions    map[string]bool
+}
+
+func regionSet(codes ...string) map[string]bool {
+	regions := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		regions[code] = true
+	}
+	return regions
+}
+
+func (m northAmericanMarket) Code() string        { return m.code }
+func (m northAmericanMarket) APIHost() string     { return m.apiHost }
+func (m northAmericanMarket) TrackerHost() string { return m.trackerHost }
+func (m northAmericanMarket) AuthHost() string    { return m.authHost }
+func (m northAmericanMarket) Currency() string    { return m.currency }
+func (m northAmericanMarket) Language() string    { return "en" }
+
+func (m northAmericanMarket) AddressLines(street, city, region, postalCode string) (string, string) {
+	return street, fmt.Sprintf("%s, %s %s", city, region, postalCode)
+}
+
+// NormalizePhone strips punctuation and the country code 1 from a North
+// American number, leaving the 10 digits Dominos expects. Ex: '+1 (555) 555-5555' is '5555555555'.
+func (m northAmericanMarket) NormalizePhone(phone string) (string, error) {
+	digits := make([]rune, 0, len(phone))
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, r)
+		case r == '+' && i == 0:
+		case strings.ContainsRune(" ()-.", r):
+		default:
+			return "", fmt.Errorf("%q can only contain digits, spaces and ()-. punctuation", phone)
+		}
+	}
+
+	if len(digits) == 11 && digits[0] == '1' {
+		digits = digits[1:]
+	}
+	if len(digits) != 10 {
+		return "", fmt.Errorf("%q must have 10 digits, optionally after the country code 1", phone)
+	}
+	if digits[0] < '2' {
+		return "", fmt.Errorf("%q has an area code starting with %c, which isn't a valid %s area code", phone, digits[0], m.code)
+	}
+	return string(digits), nil
+}
+
+// NormalizePostalCode upper cases a postal code and removes its spaces. Ex:
+// 'a1a 1a1' is 'A1A1A1'.
+func (m northAmericanMarket) NormalizePostalCode(postalCode string) (string, error) {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(postalCode), ""))
+	if !m.postalCode.MatchString(normalized) {
+		return "", fmt.Errorf("%q is not a valid %s postal code, e.g. '%s'", postalCode, m.code, m.postalCodeExample)
+	}
+	return normalized, nil
+}
+
+func (m northAmericanMarket) NormalizeRegion(region string) (string, error) {
+	normalized := strings.ToUpper(strings.TrimSpace(region))
+	if !m.regions[normalized] {
+		return "", fmt.Errorf("%q is not a %s %s code", region, m.code, m.regionName)
+	}
+	return normalized, nil
+}
@@ -0,0 +1,86 @@
+package provider
+
+import "testing"
+
+func TestNormalizePhone(t *testing.T) {
+	tests := []struct {
+		phone   string
+		want    string
+		wantErr bool
+	}{
+		{phone: "5555555555", want: "5555555555"},
+		{phone: "(555) 555-5555", want: "5555555555"},
+		{phone: "555.555.5555", want: "5555555555"},
+		{phone: "+1 555 555 5555", want: "5555555555"},
+		{phone: "15555555555", want: "5555555555"},
+		{phone: "555-5555", wantErr: true},
+		{phone: "25555555555", wantErr: true},
+		{phone: "0555555555", wantErr: true},
+		{phone: "1555555555", wantErr: true},
+		{phone: "555-555-5555 x12", wantErr: true},
+		{phone: "1+5555555555", wantErr: true},
+		{phone: "", wantErr: true},
+	}
+
+	m, _ := lookupMarket("US")
+	for _, tt := range tests {
+		got, err := m.NormalizePhone(tt.phone)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizePhone(%q) = %q, %v, want %q, error %v", tt.phone, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizePostalCode(t *testing.T) {
+	tests := []struct {
+		market     string
+		postalCode string
+		want       string
+		wantErr    bool
+	}{
+		{market: "US", postalCode: "98101", want: "98101"},
+		{market: "US", postalCode: " 98101 ", want: "98101"},
+		{market: "US", postalCode: "98101-1234", want: "98101-1234"},
+		{market: "US", postalCode: "9810", wantErr: true},
+		{market: "US", postalCode: "98101-12", wantErr: true},
+		{market: "US", postalCode: "K1A 0B1", wantErr: true},
+		{market: "CA", postalCode: "K1A 0B1", want: "K1A0B1"},
+		{market: "CA", postalCode: "k1a0b1", want: "K1A0B1"},
+		{market: "CA", postalCode: "K1A 0B", wantErr: true},
+		{market: "CA", postalCode: "98101", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		m, _ := lookupMarket(tt.market)
+		got, err := m.NormalizePostalCode(tt.postalCode)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s NormalizePostalCode(%q) = %q, %v, want %q, error %v", tt.market, tt.postalCode, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestNormalizeRegion(t *testing.T) {
+	tests := []struct {
+		market  string
+		region  string
+		want    string
+		wantErr bool
+	}{
+		{market: "US", region: "WA", want: "WA"},
+		{market: "US", region: " wa ", want: "WA"},
+		{market: "US", region: "DC", want: "DC"},
+		{market: "US", region: "Washington", wantErr: true},
+		{market: "US", region: "ON", wantErr: true},
+		{market: "CA", region: "on", want: "ON"},
+		{market: "CA", region: "WA", wantErr: true},
+		{market: "CA", region: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		m, _ := lookupMarket(tt.market)
+		got, err := m.NormalizeRegion(tt.region)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("%s NormalizeRegion(%q) = %q, %v, want %q, error %v", tt.market, tt.region, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
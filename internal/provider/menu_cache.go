@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultMenuCacheTTL is how long menus on disk are used for when the
+// provider's menu_cache block doesn't set a ttl.
+const defaultMenuCacheTTL = time.Hour
+
+// menuCache keeps menus downloaded during a run, so data sources and
+// resources looking at the same store only fetch its menu once. Menus can
+// also be kept on disk between runs, for up to ttl.
+type menuCache struct {
+	// dir is where menus are kept on disk. Empty to only cache in memory.
+	dir string
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*menuCacheEntry
+}
+
+// menuCacheEntry is a menu that is either downloaded or being downloaded.
+// done is closed once body and err are set.
+type menuCacheEntry struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+func newMenuCache(dir string, ttl time.Duration) *menuCache {
+	return &menuCache{
+		dir:     dir,
+		ttl:     ttl,
+		entries: map[string]*menuCacheEntry{},
+	}
+}
+
+// get returns the menu cached under key, calling fetch to download it if it
+// isn't cached. Concurrent callers asking for the same key share a single
+// fetch. Failed fetches aren't cached, so the next caller tries again.
+func (c *menuCache) get(key string, fetch func() ([]byte, error)) ([]byte, error) {
+	c.mu.Lock()
+	if e, ok := c.entries[key]; ok {
+		c.mu.Unlock()
+		<-e.done
+		return e.body, e.err
+	}
+	e := &menuCacheEntry{done: make(chan struct{})}
+	c.entries[key] = e
+	c.mu.Unlock()
+
+	e.body, e.err = c.load(key, fetch)
+	if e.err != nil {
+		c.mu.Lock()
+		delete(c.entries, key)
+		c.mu.Unlock()
+	}
+	close(e.done)
+
+	return e.body, e.err
+}
+
+// load reads the menu from disk if it's fresh enough, and fetches it
+// otherwise. Problems with the disk cache fall back to fetching, since the
+// cache is only there to save time.
+func (c *menuCache) load(key string, fetch func() ([]byte, error)) ([]byte, error) {
+	if c.dir == "" {
+		return fetch()
+	}
+
+	path := filepath.Join(c.dir, key+".json")
+	info, err := os.Stat(path)
+	if err == nil && time.Since(info.ModTime()) < c.ttl {
+		body, err := os.ReadFile(path)
+		if err == nil {
+			return body, nil
+		}
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fetch()
+	}
+
+	body, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	// Written next to the file and renamed into place, so another run
+	// reading the cache never sees half a menu.
+	err = os.MkdirAll(c.dir, 0o755)
+	if err == nil {
+		tmp := fmt.Sprintf("%s.%d.tmp", path, os.Getpid())
+		err = os.WriteFile(tmp, body, 0o644)
+		if err == nil {
+			os.Rename(tmp, path)
+		}
+	}
+	return body, nil
+}
@@ -0,0 +1,194 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// order is the payload the price-order and place-order endpoints expect.
+type order struct {
+	Address               json.RawMessage
+	Coupons               []interface{}
+	CustomerID            string
+	Email                 string
+	FirstName             string
+	LastName              string
+	LanguageCode          string
+	OrderChannel          string
+	OrderID               string
+	OrderMethod           string
+	Payments              []orderPayment
+	Phone                 string
+	Products              []orderProduct
+	ServiceMethod         string
+	SourceOrganizationURI string
+	StoreID               string
+	Version               string
+	NoCombine             bool
+}
+
+type orderProduct struct {
+	Code    string
+	Qty     int
+	ID      int
+	IsNew   bool `json:"isNew"`
+	Options map[string]interface{}
+}
+
+// orderCoupon is a coupon applied to an order, like a loyalty reward.
+type orderCoupon struct {
+	Code  string
+	Qty   int
+	ID    int
+	IsNew bool `json:"isNew"`
+}
+
+type orderPayment struct {
+	Type         string
+	Amount       float64
+	CardType     string `json:",omitempty"`
+	Number       string `json:",omitempty"`
+	Expiration   string `json:",omitempty"`
+	SecurityCode string `json:",omitempty"`
+	PostalCode   string `json:",omitempty"`
+	TipAmount    float64
+}
+
+type orderRequest struct {
+	Order order
+}
+
+type orderResponse struct {
+	Status      int
+	StatusItems []statusItem
+	Order       struct {
+		OrderID              string
+		EstimatedWaitMinutes string
+		StatusItems          []statusItem
+		Amounts              struct {
+			Menu      float64
+			Discount  float64
+			Surcharge float64
+			Tax       float64
+			Customer  float64
+		}
+		AmountsBreakdown struct {
+			DeliveryFee json.Number
+		}
+		Products []orderResponseProduct
+	}
+}
+
+// orderResponseProduct is a product in a response, with any problems Dominos
+// found with it. ID matches the orderProduct it was sent as.
+type orderResponseProduct struct {
+	ID          int
+	Code        string
+	Status      int
+	StatusItems []statusItem
+}
+
+type statusItem struct {
+	Code    string
+	Message string
+}
+
+// newOrder builds an order for the given store, address, items and customer.
+// It has no payments attached.
+func (p dominosProvider) newOrder(storeID int64, addressAPIObj string, itemCodes []string, c customer) order {
+	products := make([]orderProduct, 0, len(itemCodes))
+	for i, code := range itemCodes {
+		products = append(products, orderProduct{
+			Code:    code,
+			Qty:     1,
+			ID:      i + 1,
+			IsNew:   true,
+			Options: map[string]interface{}{},
+		})
+	}
+
+	return order{
+		Address:               json.RawMessage(addressAPIObj),
+		Coupons:               []interface{}{},
+		Email:                 c.email,
+		FirstName:             c.firstName,
+		LastName:              c.lastName,
+		LanguageCode:          p.language,
+		OrderChannel:          "OLO",
+		OrderMethod:           "Web",
+		Payments:              []orderPayment{},
+		Phone:                 c.phone,
+		Products:              products,
+		ServiceMethod:         "Delivery",
+		SourceOrganizationURI: strings.TrimPrefix(p.market.APIHost(), "https://"),
+		StoreID:               strconv.FormatInt(storeID, 10),
+		Version:               "1.0",
+		NoCombine:             true,
+	}
+}
+
+// validateOrder checks the products can be ordered from the store, without
+// pricing the order. It is safe to retry.
+func validateOrder(ctx context.Context, url string, o order, client *http.Client) (orderResponse, error) {
+	return postOrder(ctx, url, o, client, true)
+}
+
+// priceOrder is safe to retry, since pricing an order has no side effects.
+func priceOrder(ctx context.Context, url string, o order, client *http.Client) (orderResponse, error) {
+	return postOrder(ctx, url, o, client, true)
+}
+
+// placeOrder is never retried: if the request times out, the order may have
+// gone through anyway.
+func placeOrder(ctx context.Context, url string, o order, client *http.Client) (orderResponse, error) {
+	return postOrder(ctx, url, o, client, false)
+}
+
+func postOrder(ctx context.Context, endpoint string, o order, client *http.Client, retry bool) (orderResponse, error) {
+	resp := orderResponse{}
+
+	body, err := json.Marshal(orderRequest{Order: o})
+	if err != nil {
+		return resp, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Referer", fmt.Sprintf("%s://%s/en/pages/order/", req.URL.Scheme, req.URL.Host))
+	authorize(req)
+	if retry {
+		req = withRetries(req)
+	}
+
+	r, err := client.Do(req)
+	if err != nil {
+		return resp, err
+	}
+	defer r.Body.Close()
+
+	err = checkResponse(r)
+	if err != nil {
+		return resp, err
+	}
+
+	err = json.NewDecoder(r.Body).Decode(&resp)
+	if err != nil {
+		return resp, &decodeError{URL: endpoint, Err: err}
+	}
+
+	// A status of -1 means Dominos rejected the order
+	if resp.Status == -1 {
+		e := newAPIError(resp.StatusItems, resp.Order.StatusItems)
+		e.addProducts(resp.Order.Products)
+		return resp, e
+	}
+	return resp, nil
+}
@@ -0,0 +1,237 @@
+package provider
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// paymentProfileData is one of the provider's payment profiles. Exactly one
+// of Card, GiftCard and Cash is set.
+type paymentProfileData struct {
+	Name     types.String    `tfsdk:"name"`
+	Card     *creditCardData `tfsdk:"card"`
+	GiftCard *giftCardData   `tfsdk:"gift_card"`
+	Cash     types.Bool      `tfsdk:"cash"`
+}
+
+type giftCardData struct {
+	Number types.String `tfsdk:"number"`
+	Pin    types.String `tfsdk:"pin"`
+}
+
+// creditCardAttributes is the schema of a credit card, shared by credit_card
+// and the card of a payment profile.
+func creditCardAttributes() map[string]tfsdk.Attribute {
+	return map[string]tfsdk.Attribute{
+		"number": {
+			Description: "The credit card number.",
+			Type:        types.Int64Type,
+			Required:    true,
+		},
+		"cvv": {
+			Description: "The credit card CVV, 3 or 4 digits. A string, so CVVs starting with 0 keep it. Ex: '012'.",
+			Type:        types.StringType,
+			Required:    true,
+		},
+		"date": {
+			Description: "The credit card expiration date, as MM/YY. MM/YYYY and MMYY also work.",
+			Type:        types.StringType,
+			Required:    true,
+		},
+		"postal_code": {
+			Description: "The postal code attached to the credit card.",
+			Type:        types.StringType,
+			Required:    true,
+		},
+		"card_type": {
+			Description: "The credit card type. Default: 'VISA'.",
+			Type:        types.StringType,
+			Optional:    true,
+		},
+	}
+}
+
+var (
+	cardCVV = regexp.MustCompile(`^[0-9]{3,4}$`)
+
+	// cardExpiration matches MM/YY, and the MM/YYYY, MMYY and MM-YY forms
+	// older versions of the provider passed straight through to Dominos.
+	cardExpiration = regexp.MustCompile(`^(0?[1-9]|1[0-2]) ?[/-]? ?([0-9]{4}|[0-9]{2})$`)
+)
+
+// parseCardExpiration returns the month and 2 digit year of an expiration date.
+func parseCardExpiration(date string) (int, int, bool) {
+	m := cardExpiration.FindStringSubmatch(strings.TrimSpace(date))
+	if m == nil {
+		return 0, 0, false
+	}
+	month, _ := strconv.Atoi(m[1])
+	year, _ := strconv.Atoi(m[2])
+	return month, year % 100, true
+}
+
+// validateCreditCard checks a card's number, CVV and expiration date look
+// right, so a typo fails when the provider is configured rather than when an
+// order is placed. It also fills in the default card type.
+func validateCreditCard(card *creditCardData, at path.Path, now time.Time) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if card.CardType.Null || card.CardType.Value == "" {
+		card.CardType = types.String{Value: string("VISA")}
+	}
+
+	if !luhnValid(strconv.FormatInt(card.CreditCardNumber.Value, 10)) {
+		diags.AddAttributeError(at.AtName("number"), "Invalid card number", "The card number is not a valid card number. Check it for typos.")
+	}
+
+	if !cardCVV.MatchString(card.Cvv.Value) {
+		diags.AddAttributeError(at.AtName("cvv"), "Invalid CVV", "The CVV must be 3 or 4 digits.")
+	}
+
+	month, year, ok := parseCardExpiration(card.ExprDate.Value)
+	if !ok {
+		diags.AddAttributeError(at.AtName("date"), "Invalid expiration date", fmt.Sprintf("The expiration date must be in MM/YY format, e.g. '01/30', got %q.", card.ExprDate.Value))
+		return diags
+	}
+	// Cards expire at the end of their expiration month
+	expires := time.Date(2000+year, time.Month(month)+1, 1, 0, 0, 0, 0, now.Location())
+	if !now.Before(expires) {
+		diags.AddAttributeError(at.AtName("date"), "Expired card", fmt.Sprintf("The card expired at the end of %s.", card.ExprDate.Value))
+	}
+	return diags
+}
+
+// luhnValid checks a card number's check digit.
+func luhnValid(number string) bool {
+	if len(number) < 12 || len(number) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		d := int(number[i] - '0')
+		if d < 0 || d > 9 {
+			return false
+		}
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// validatePaymentProfile checks a payment profile has exactly one way to pay,
+// and that a card in it is valid.
+func validatePaymentProfile(profile *paymentProfileData, at path.Path, now time.Time) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	methods := 0
+	if profile.Card != nil {
+		methods++
+		diags.Append(validateCreditCard(profile.Card, at.AtName("card"), now)...)
+	}
+	if profile.GiftCard != nil {
+		methods++
+		if strings.TrimSpace(profile.GiftCard.Number.Value) == "" {
+			diags.AddAttributeError(at.AtName("gift_card").AtName("number"), "Invalid gift card number", "The gift card number can't be empty.")
+		}
+	}
+	if profile.Cash.Value {
+		methods++
+	}
+	if methods != 1 {
+		diags.AddAttributeError(at, "Invalid payment profile", fmt.Sprintf("Payment profile %q must have exactly one of card, gift_card or cash = true.", profile.Name.Value))
+	}
+	return diags
+}
+
+// paymentProfileNames lists the configured payment profiles, for error messages.
+func (p dominosProvider) paymentProfileNames() string {
+	names := make([]string, 0, len(p.paymentProfiles))
+	for name := range p.paymentProfiles {
+		names = append(names, fmt.Sprintf("%q", name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// checkPaymentProfile returns an error if an order can't be paid for with the
+// named payment profile. An empty name means the provider's credit_card.
+func (p dominosProvider) checkPaymentProfile(name string) error {
+	if name == "" {
+		if p.creditCard == nil {
+			if len(p.paymentProfiles) > 0 {
+				return fmt.Errorf("the provider has no credit_card, so payment_profile must be set to one of %s", p.paymentProfileNames())
+			}
+			return fmt.Errorf("a credit_card or payment_profile must be configured on the provider to place an order")
+		}
+		return nil
+	}
+	if _, ok := p.paymentProfiles[name]; !ok {
+		if len(p.paymentProfiles) == 0 {
+			return fmt.Errorf("payment profile %q doesn't exist, the provider has no payment_profile blocks", name)
+		}
+		return fmt.Errorf("payment profile %q doesn't exist, it must be one of %s", name, p.paymentProfileNames())
+	}
+	return nil
+}
+
+// payment pays the full amount using the named payment profile, or the
+// provider's credit_card if name is empty.
+func (p dominosProvider) payment(name string, amount float64) (orderPayment, error) {
+	err := p.checkPaymentProfile(name)
+	if err != nil {
+		return orderPayment{}, err
+	}
+	if name == "" {
+		return cardPayment(p.creditCard, amount), nil
+	}
+
+	profile := p.paymentProfiles[name]
+	switch {
+	case profile.Card != nil:
+		return cardPayment(profile.Card, amount), nil
+	case profile.GiftCard != nil:
+		return orderPayment{
+			Type:         "GiftCard",
+			Amount:       amount,
+			Number:       profile.GiftCard.Number.Value,
+			SecurityCode: profile.GiftCard.Pin.Value,
+		}, nil
+	default:
+		return orderPayment{
+			Type:   "Cash",
+			Amount: amount,
+		}, nil
+	}
+}
+
+func cardPayment(card *creditCardData, amount float64) orderPayment {
+	// Cards are validated when the provider is configured
+	month, year, _ := parseCardExpiration(card.ExprDate.Value)
+
+	return orderPayment{
+		Type:         "CreditCard",
+		Amount:       amount,
+		CardType:     card.CardType.Value,
+		Number:       strconv.FormatInt(card.CreditCardNumber.Value, 10),
+		Expiration:   fmt.Sprintf("%02d%02d", month, year),
+		SecurityCode: card.Cvv.Value,
+		PostalCode:   card.PostalCode.Value,
+	}
+}
@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestLuhnValid(t *testing.T) {
+	tests := map[string]bool{
+		"4111111111111111":     true,
+		"5555555555554444":     true,
+		"378282246310005":      true,
+		"4111111111111112":     false,
+		"123456789101112":      false,
+		"41111111111":          false,
+		"41111111111111111111": false,
+		"4111x11111111111":     false,
+	}
+	for number, want := range tests {
+		if got := luhnValid(number); got != want {
+			t.Errorf("luhnValid(%q) = %v, want %v", number, got, want)
+		}
+	}
+}
+
+func TestParseCardExpiration(t *testing.T) {
+	tests := []struct {
+		date  string
+		month int
+		year  int
+		ok    bool
+	}{
+		{"01/30", 1, 30, true},
+		{"1/30", 1, 30, true},
+		{"12/2031", 12, 31, true},
+		{"0130", 1, 30, true},
+		{"1230", 12, 30, true},
+		{"01-30", 1, 30, true},
+		{" 01 / 30 ", 1, 30, true},
+		{"13/30", 0, 0, false},
+		{"00/30", 0, 0, false},
+		{"01/3", 0, 0, false},
+		{"January 2030", 0, 0, false},
+		{"", 0, 0, false},
+	}
+	for _, tt := range tests {
+		month, year, ok := parseCardExpiration(tt.date)
+		if month != tt.month || year != tt.year || ok != tt.ok {
+			t.Errorf("parseCardExpiration(%q) = %d, %d, %v, want %d, %d, %v", tt.date, month, year, ok, tt.month, tt.year, tt.ok)
+		}
+	}
+}
+
+func TestValidateCreditCard(t *testing.T) {
+	now := time.Date(2026, time.March, 15, 12, 0, 0, 0, time.UTC)
+	valid := func() *creditCardData {
+		return &creditCardData{
+			CreditCardNumber: types.Int64{Value: 4111111111111111},
+			Cvv:              types.String{Value: "123"},
+			ExprDate:         types.String{Value: "01/30"},
+			PostalCode:       types.String{Value: "02122"},
+			CardType:         types.String{Null: true},
+		}
+	}
+
+	tests := map[string]struct {
+		modify func(*creditCardData)
+		errAt  string
+	}{
+		"valid":              {modify: func(c *creditCardData) {}},
+		"4 digit cvv":        {modify: func(c *creditCardData) { c.Cvv = types.String{Value: "1234"} }},
+		"cvv with leading 0": {modify: func(c *creditCardData) { c.Cvv = types.String{Value: "012"} }},
+		"expires this month": {modify: func(c *creditCardData) { c.ExprDate = types.String{Value: "03/26"} }},
+		"long year":          {modify: func(c *creditCardData) { c.ExprDate = types.String{Value: "01/2030"} }},
+		"bad number":         {modify: func(c *creditCardData) { c.CreditCardNumber = types.Int64{Value: 4111111111111112} }, errAt: "number"},
+		"1 digit cvv":        {modify: func(c *creditCardData) { c.Cvv = types.String{Value: "7"} }, errAt: "cvv"},
+		"2 digit cvv":        {modify: func(c *creditCardData) { c.Cvv = types.String{Value: "12"} }, errAt: "cvv"},
+		"5 digit cvv":        {modify: func(c *creditCardData) { c.Cvv = types.String{Value: "12345"} }, errAt: "cvv"},
+		"expired":            {modify: func(c *creditCardData) { c.ExprDate = types.String{Value: "02/26"} }, errAt: "date"},
+		"invalid date":       {modify: func(c *creditCardData) { c.ExprDate = types.String{Value: "15/16"} }, errAt: "date"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			card := valid()
+			tt.modify(card)
+
+			diags := validateCreditCard(card, path.Root("credit_card"), now)
+			if tt.errAt == "" {
+				if diags.HasError() {
+					t.Errorf("unexpected errors: %v", diags)
+				}
+				return
+			}
+			if diags.ErrorsCount() != 1 {
+				t.Fatalf("got %d errors, want 1: %v", diags.ErrorsCount(), diags)
+			}
+			want := path.Root("credit_card").AtName(tt.errAt)
+			if got := diags.Errors()[0].(interface{ Path() path.Path }).Path(); !got.Equal(want) {
+				t.Errorf("error is on %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestValidateCreditCardDefaultsCardType(t *testing.T) {
+	card := &creditCardData{
+		CreditCardNumber: types.Int64{Value: 4111111111111111},
+		Cvv:              types.String{Value: "123"},
+		ExprDate:         types.String{Value: "01/30"},
+		CardType:         types.String{Null: true},
+	}
+	validateCreditCard(card, path.Root("credit_card"), time.Now())
+	if card.CardType.Value != "VISA" {
+		t.Errorf("card type is %q, want VISA", card.CardType.Value)
+	}
+}
+
+func TestCardPayment(t *testing.T) {
+	for _, date := range []string{"01/30", "1/2030", "0130"} {
+		payment := cardPayment(&creditCardData{
+			CreditCardNumber: types.Int64{Value: 4111111111111111},
+			Cvv:              types.String{Value: "012"},
+			ExprDate:         types.String{Value: date},
+			PostalCode:       types.String{Value: "02122"},
+			CardType:         types.String{Value: "VISA"},
+		}, 12.5)
+
+		if payment.Expiration != "0130" {
+			t.Errorf("expiration %q is sent as %q, want 0130", date, payment.Expiration)
+		}
+		if payment.SecurityCode != "012" {
+			t.Errorf("security code is %q, want 012", payment.SecurityCode)
+		}
+		if payment.Number != "4111111111111111" {
+			t.Errorf("number is %q, want 4111111111111111", payment.Number)
+		}
+	}
+}
@@ -3,8 +3,13 @@ package provider
 import (
 	"context"
 	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
@@ -16,18 +21,59 @@ var _ provider.Provider = &dominosProvider{}
 // dominosProvider satisfies the provider.Provider interface and usually is included
 // with all Resource and DataSource implementations.
 type dominosProvider struct {
-	// client can contain the upstream provider SDK or HTTP client used to
-	// communicate with the upstream service. Resource and DataSource
-	// implementations can then make calls using this client.
-	//
-	// TODO: If appropriate, implement upstream provider SDK or HTTP client.
-	// client vendorsdk.ExampleClient
+	// client is the HTTP client used to communicate with Dominos. It is
+	// shared by every Resource and DataSource so that retries and rate
+	// limiting apply across all of them.
+	client *http.Client
 
 	// configured is set to true at the end of the Configure method.
 	// This can be used in Resource and DataSource implementations to verify
 	// that the provider was previously configured.
 	configured bool
 
+	// market is the Dominos country all API calls are made against.
+	market market
+
+	// language is the default language for menus, falling back to the
+	// market's language when unset.
+	language string
+
+	// maxOrderTotalCents and maxItemsPerOrder guard against accidentally
+	// huge orders. Zero means no limit.
+	maxOrderTotalCents int64
+	maxItemsPerOrder   int64
+
+	// Customer and payment details sent with every order.
+	firstName   string
+	lastName    string
+	emailAddr   string
+	phoneNumber string
+	creditCard  *creditCardData
+
+	// paymentProfiles are the named ways to pay, by name.
+	paymentProfiles map[string]paymentProfileData
+
+	// loyalty is the rewards account orders can redeem rewards from. Nil
+	// when no loyalty block is configured.
+	loyalty *loyaltySession
+
+	// approvalSecret signs order approval tokens. Orders priced above
+	// approvalThresholdCents must carry a valid token.
+	approvalSecret         string
+	approvalThresholdCents int64
+
+	// budget caps spending per period, tracked in a local ledger. Nil when
+	// no budget is configured.
+	budget *budgetConfig
+
+	// idempotencyPath is the local file recently placed order keys are kept
+	// in, to stop the same order being placed twice.
+	idempotencyPath string
+
+	// menus caches store menus for the life of the provider instance. A
+	// pointer, so every Resource and DataSource shares the same cache.
+	menus *menuCache
+
 	// version is set to the provider version on release, "dev" when the
 	// provider is built and ran locally, and "test" when running acceptance
 	// testing.
@@ -40,11 +86,51 @@ type providerData struct {
 	EmailAddr   types.String    `tfsdk:"email_address"`
 	PhoneNumber types.String    `tfsdk:"phone_number"`
 	CreditCard  *creditCardData `tfsdk:"credit_card"`
+
+	PaymentProfiles []paymentProfileData `tfsdk:"payment_profile"`
+
+	Loyalty *loyaltyData `tfsdk:"loyalty"`
+
+	Market   types.String `tfsdk:"market"`
+	Language types.String `tfsdk:"language"`
+
+	MaxOrderTotal    types.Float64 `tfsdk:"max_order_total"`
+	MaxItemsPerOrder types.Int64   `tfsdk:"max_items_per_order"`
+
+	ApprovalSecret    types.String  `tfsdk:"approval_secret"`
+	ApprovalThreshold types.Float64 `tfsdk:"approval_threshold"`
+
+	Budget *budgetData `tfsdk:"budget"`
+
+	IdempotencyFile types.String `tfsdk:"idempotency_file"`
+
+	MaxRetries        types.Int64   `tfsdk:"max_retries"`
+	RequestsPerSecond types.Float64 `tfsdk:"requests_per_second"`
+
+	MenuCache *menuCacheData `tfsdk:"menu_cache"`
+
+	Recording *recordingData `tfsdk:"recording"`
+}
+
+type recordingData struct {
+	Mode        types.String `tfsdk:"mode"`
+	CassetteDir types.String `tfsdk:"cassette_dir"`
+}
+
+type menuCacheData struct {
+	Directory types.String `tfsdk:"directory"`
+	TTL       types.String `tfsdk:"ttl"`
+}
+
+type budgetData struct {
+	Amount     types.Float64 `tfsdk:"amount"`
+	Period     types.String  `tfsdk:"period"`
+	LedgerPath types.String  `tfsdk:"ledger_path"`
 }
 
 type creditCardData struct {
 	CreditCardNumber types.Int64  `tfsdk:"number"`
-	Cvv              types.Int64  `tfsdk:"cvv"`
+	Cvv              types.String `tfsdk:"cvv"`
 	ExprDate         types.String `tfsdk:"date"`
 	PostalCode       types.String `tfsdk:"postal_code"`
 	CardType         types.String `tfsdk:"card_type"`
@@ -59,7 +145,161 @@ func (p *dominosProvider) Configure(ctx context.Context, req provider.ConfigureR
 		return
 	}
 
-	data.CreditCard.CardType = types.String{Value: string("VISA")}
+	now := time.Now()
+	if data.CreditCard != nil {
+		resp.Diagnostics.Append(validateCreditCard(data.CreditCard, path.Root("credit_card"), now)...)
+	}
+
+	profiles := make(map[string]paymentProfileData, len(data.PaymentProfiles))
+	for i := range data.PaymentProfiles {
+		profile := &data.PaymentProfiles[i]
+		at := path.Root("payment_profile").AtListIndex(i)
+
+		resp.Diagnostics.Append(validatePaymentProfile(profile, at, now)...)
+		if _, ok := profiles[profile.Name.Value]; ok {
+			resp.Diagnostics.AddAttributeError(at.AtName("name"), "Duplicate payment profile", fmt.Sprintf("There is more than one payment profile named %q.", profile.Name.Value))
+		}
+		profiles[profile.Name.Value] = *profile
+	}
+
+	if data.Market.Null {
+		data.Market = types.String{Value: defaultMarket}
+	}
+	m, err := lookupMarket(data.Market.Value)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("market"), "Invalid market", err.Error())
+		return
+	}
+	p.market = m
+
+	p.language = m.Language()
+	if !data.Language.Null && data.Language.Value != "" {
+		p.language = strings.ToLower(data.Language.Value)
+	}
+
+	if data.EmailAddr.Value != "" {
+		if err := validateEmail(data.EmailAddr.Value); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("email_address"), "Invalid email_address", err.Error())
+		}
+	}
+	if data.PhoneNumber.Value != "" {
+		phone, err := m.NormalizePhone(data.PhoneNumber.Value)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("phone_number"), "Invalid phone_number", err.Error())
+		}
+		data.PhoneNumber = types.String{Value: phone}
+	}
+
+	if !data.MaxOrderTotal.Null {
+		if data.MaxOrderTotal.Value <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_order_total"), "Invalid max_order_total", "The maximum order total must be greater than zero.")
+		}
+		p.maxOrderTotalCents = int64(math.Round(data.MaxOrderTotal.Value * 100))
+	}
+	if !data.MaxItemsPerOrder.Null {
+		if data.MaxItemsPerOrder.Value <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_items_per_order"), "Invalid max_items_per_order", "The maximum number of items per order must be greater than zero.")
+		}
+		p.maxItemsPerOrder = data.MaxItemsPerOrder.Value
+	}
+	if !data.ApprovalThreshold.Null {
+		if data.ApprovalSecret.Null || data.ApprovalSecret.Value == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("approval_threshold"), "Missing approval_secret", "An approval_secret is required to verify approval tokens when approval_threshold is set.")
+		}
+		p.approvalThresholdCents = int64(math.Round(data.ApprovalThreshold.Value * 100))
+	}
+	p.approvalSecret = data.ApprovalSecret.Value
+
+	if data.Budget != nil {
+		if data.Budget.Amount.Value <= 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("budget").AtName("amount"), "Invalid budget amount", "The budget amount must be greater than zero.")
+		}
+		period := strings.ToLower(data.Budget.Period.Value)
+		if period != "weekly" && period != "monthly" {
+			resp.Diagnostics.AddAttributeError(path.Root("budget").AtName("period"), "Invalid budget period", fmt.Sprintf("The budget period must be 'weekly' or 'monthly', got %q.", data.Budget.Period.Value))
+		}
+		p.budget = &budgetConfig{
+			amountCents: int64(math.Round(data.Budget.Amount.Value * 100)),
+			period:      period,
+			ledgerPath:  data.Budget.LedgerPath.Value,
+		}
+	}
+
+	maxRetries := int64(defaultMaxRetries)
+	if !data.MaxRetries.Null {
+		if data.MaxRetries.Value < 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("max_retries"), "Invalid max_retries", "The number of retries can't be negative.")
+		}
+		maxRetries = data.MaxRetries.Value
+	}
+	if data.RequestsPerSecond.Value < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("requests_per_second"), "Invalid requests_per_second", "The request rate can't be negative.")
+	}
+
+	menuCacheDir := ""
+	menuCacheTTL := defaultMenuCacheTTL
+	if data.MenuCache != nil {
+		menuCacheDir = data.MenuCache.Directory.Value
+		if !data.MenuCache.TTL.Null && data.MenuCache.TTL.Value != "" {
+			menuCacheTTL, err = time.ParseDuration(data.MenuCache.TTL.Value)
+			if err != nil || menuCacheTTL <= 0 {
+				resp.Diagnostics.AddAttributeError(path.Root("menu_cache").AtName("ttl"), "Invalid menu cache ttl", fmt.Sprintf("The ttl must be a positive duration, e.g. '1h', got %q.", data.MenuCache.TTL.Value))
+			}
+		}
+	}
+
+	if data.Loyalty != nil {
+		if strings.TrimSpace(data.Loyalty.Username.Value) == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("loyalty").AtName("username"), "Invalid loyalty username", "The username can't be empty.")
+		}
+		if data.Loyalty.Password.Value == "" {
+			resp.Diagnostics.AddAttributeError(path.Root("loyalty").AtName("password"), "Invalid loyalty password", "The password can't be empty.")
+		}
+	}
+
+	recordingMode := recordingOff
+	if data.Recording != nil {
+		recordingMode = strings.ToLower(data.Recording.Mode.Value)
+		switch recordingMode {
+		case recordingOff:
+		case recordingRecord, recordingReplay:
+			if data.Recording.CassetteDir.Null || data.Recording.CassetteDir.Value == "" {
+				resp.Diagnostics.AddAttributeError(path.Root("recording").AtName("cassette_dir"), "Missing cassette_dir", fmt.Sprintf("A cassette_dir is required to %s.", recordingMode))
+			}
+		default:
+			resp.Diagnostics.AddAttributeError(path.Root("recording").AtName("mode"), "Invalid recording mode", fmt.Sprintf("The recording mode must be '%s', '%s' or '%s', got %q.", recordingOff, recordingRecord, recordingReplay, data.Recording.Mode.Value))
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	p.client = newHTTPClient(int(maxRetries), data.RequestsPerSecond.Value)
+
+	p.menus = newMenuCache(menuCacheDir, menuCacheTTL)
+
+	p.idempotencyPath = defaultIdempotencyPath()
+	if !data.IdempotencyFile.Null && data.IdempotencyFile.Value != "" {
+		p.idempotencyPath = data.IdempotencyFile.Value
+	}
+
+	p.firstName = data.FirstName.Value
+	p.lastName = data.LastName.Value
+	p.emailAddr = data.EmailAddr.Value
+	p.phoneNumber = data.PhoneNumber.Value
+	p.creditCard = data.CreditCard
+	p.paymentProfiles = profiles
+	if data.Loyalty != nil {
+		p.loyalty = &loyaltySession{
+			username: strings.TrimSpace(data.Loyalty.Username.Value),
+			password: data.Loyalty.Password.Value,
+		}
+	}
+
+	if recordingMode != recordingOff {
+		p.client.Transport = newRecordingTransport(p.client.Transport, recordingMode, data.Recording.CassetteDir.Value, p.recordingSecrets())
+	}
 
 	p.configured = true
 }
@@ -72,10 +312,13 @@ func (p *dominosProvider) GetResources(ctx context.Context) (map[string]provider
 
 func (p *dominosProvider) GetDataSources(ctx context.Context) (map[string]provider.DataSourceType, diag.Diagnostics) {
 	return map[string]provider.DataSourceType{
-		"dominos_address":   dataSourceAddressType{},
-		"dominos_store":     dataSourceStoreType{},
-		"dominos_menu":      dataSourceMenuType{},
-		"dominos_menu_item": dataSourceMenuItemType{},
+		"dominos_address":        dataSourceAddressType{},
+		"dominos_store":          dataSourceStoreType{},
+		"dominos_menu":           dataSourceMenuType{},
+		"dominos_menu_item":      dataSourceMenuItemType{},
+		"dominos_menu_diff":      dataSourceMenuDiffType{},
+		"dominos_loyalty":        dataSourceLoyaltyType{},
+		"dominos_order_approval": dataSourceOrderApprovalType{},
 	}, nil
 }
 
@@ -89,53 +332,182 @@ Use the navigation to the right to read about the available resources.
 		`,
 		Attributes: map[string]tfsdk.Attribute{
 			"email_address": {
-				Description: "The email address to receive order updates and a receipt to.",
-				Required:    true,
+				Description: "The email address to receive order updates and a receipt to. Can be overridden by the customer block of a dominos_order.",
+				Optional:    true,
 				Type:        types.StringType,
 			},
 			"first_name": {
-				Description: "Your first name.",
-				Required:    true,
+				Description: "Your first name. Can be overridden by the customer block of a dominos_order.",
+				Optional:    true,
 				Type:        types.StringType,
 			},
 			"last_name": {
-				Description: "Your last name.",
-				Required:    true,
+				Description: "Your last name. Can be overridden by the customer block of a dominos_order.",
+				Optional:    true,
 				Type:        types.StringType,
 			},
 			"phone_number": {
-				Description: "The phone number Dominos will call if any issues arise.",
-				Required:    true,
+				Description: "The phone number Dominos will call if any issues arise, e.g. '15555555555'. Punctuation and the country code are stripped. Can be overridden by the customer block of a dominos_order.",
+				Optional:    true,
 				Type:        types.StringType,
 			},
-			"credit_card": {
-				Description: "Your actual credit card THAT WILL GET CHARGED.",
+			"market": {
+				Description: "The country to order from, which selects the API host, address format, currency and language. One of 'US' or 'CA'. Default: 'US'.",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"language": {
+				Description: "The default language for menu item names, e.g. 'fr' for French. Can be overridden per data source. Default: the market's language ('en').",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"max_order_total": {
+				Description: "The most a single dominos_order may cost, in the market's currency. Orders estimated above this from menu prices fail at plan time, and orders priced above it including taxes, fees and the tip fail before they are placed.",
+				Optional:    true,
+				Type:        types.Float64Type,
+			},
+			"max_items_per_order": {
+				Description: "The most items a single dominos_order may contain. Orders with more items fail at plan time, or before they are placed when the plan can't tell yet.",
+				Optional:    true,
+				Type:        types.Int64Type,
+			},
+			"approval_secret": {
+				Description: "The shared secret used to sign and verify dominos_order approval tokens.",
 				Optional:    true,
 				Sensitive:   true,
+				Type:        types.StringType,
+			},
+			"approval_threshold": {
+				Description: "Orders priced above this amount, in the market's currency, need an approval token signed with approval_secret before they are placed.",
+				Optional:    true,
+				Type:        types.Float64Type,
+			},
+			"budget": {
+				Description: "A spending budget shared by every dominos_order. Placed orders are recorded in a local JSON ledger, and orders that would go over the budget for the current period fail at plan time (estimated from menu prices) and again before they are placed (with taxes, fees and the tip).",
+				Optional:    true,
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"amount": {
+						Description: "The most that can be spent per period, in the market's currency.",
+						Type:        types.Float64Type,
+						Required:    true,
+					},
+					"period": {
+						Description: "How often the budget resets. One of 'weekly' (on Mondays) or 'monthly'.",
+						Type:        types.StringType,
+						Required:    true,
+					},
+					"ledger_path": {
+						Description: "The path of the JSON ledger that placed orders are recorded in. Created if it doesn't exist.",
+						Type:        types.StringType,
+						Required:    true,
+					},
+				}),
+			},
+			"idempotency_file": {
+				Description: "The local JSON file that recently placed orders are recorded in, so the same order is never placed twice (e.g. when retrying an apply that timed out). Default: 'terraform-provider-dominos/orders.json' in the user's cache directory.",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"max_retries": {
+				Description: "How many times to retry a request that fails with a connection error, a 429 or a 5xx, with exponential backoff. Placing an order is never retried. Default: 3.",
+				Optional:    true,
+				Type:        types.Int64Type,
+			},
+			"requests_per_second": {
+				Description: "The most requests per second to send to Dominos, across all data sources and resources. Default: unlimited.",
+				Optional:    true,
+				Type:        types.Float64Type,
+			},
+			"menu_cache": {
+				Description: "Keep downloaded menus on disk between runs. Menus are always shared between data sources and resources within a run, this also shares them between runs.",
+				Optional:    true,
 				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
-					"number": {
-						Description: "The credit card number.",
-						Type:        types.Int64Type,
+					"directory": {
+						Description: "The directory menus are kept in. Created if it doesn't exist.",
+						Type:        types.StringType,
 						Required:    true,
 					},
-					"cvv": {
-						Description: "The credit card CVV.",
-						Type:        types.Int64Type,
+					"ttl": {
+						Description: "How long a menu on disk is used for before downloading it again, as a duration. Ex: '30m'. Default: '1h'.",
+						Type:        types.StringType,
+						Optional:    true,
+					},
+				}),
+			},
+			"recording": {
+				Description: "Record every request to Dominos and its response to a directory of cassettes, or replay them without touching the network. Names, contact details and card details are redacted from cassettes.",
+				Optional:    true,
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"mode": {
+						Description: "One of 'off', 'record' (send requests and save the responses) or 'replay' (answer requests from saved responses only).",
+						Type:        types.StringType,
 						Required:    true,
 					},
-					"date": {
-						Description: "The credit card expiration date.",
+					"cassette_dir": {
+						Description: "The directory cassettes are written to and read from. Required unless mode is 'off'.",
+						Type:        types.StringType,
+						Optional:    true,
+					},
+				}),
+			},
+			"credit_card": {
+				Description: "Your actual credit card THAT WILL GET CHARGED. Used by every dominos_order that doesn't set a payment_profile.",
+				Optional:    true,
+				Sensitive:   true,
+				Attributes:  tfsdk.SingleNestedAttributes(creditCardAttributes()),
+			},
+			"loyalty": {
+				Description: "A Dominos rewards account to log in to, for the dominos_loyalty data source and the redeem_reward of a dominos_order. The provider only logs in when one of them needs it.",
+				Optional:    true,
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"username": {
+						Description: "The email address the rewards account is registered with.",
 						Type:        types.StringType,
 						Required:    true,
 					},
-					"postal_code": {
-						Description: "The postal code attached to the credit card.",
+					"password": {
+						Description: "The rewards account's password.",
 						Type:        types.StringType,
 						Required:    true,
+						Sensitive:   true,
 					},
-					"card_type": {
-						Description: "The credit card type. Default: 'VISA'.",
+				}),
+			},
+			"payment_profile": {
+				Description: "Named ways to pay, so different teams or cost centres can be charged from the same configuration. Each has exactly one of card, gift_card or cash, and a dominos_order picks one with its payment_profile.",
+				Optional:    true,
+				Attributes: tfsdk.ListNestedAttributes(map[string]tfsdk.Attribute{
+					"name": {
+						Description: "The name a dominos_order uses to pick this profile.",
 						Type:        types.StringType,
+						Required:    true,
+					},
+					"card": {
+						Description: "A credit card THAT WILL GET CHARGED.",
+						Optional:    true,
+						Sensitive:   true,
+						Attributes:  tfsdk.SingleNestedAttributes(creditCardAttributes()),
+					},
+					"gift_card": {
+						Description: "A Dominos gift card.",
+						Optional:    true,
+						Sensitive:   true,
+						Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+							"number": {
+								Description: "The gift card number.",
+								Type:        types.StringType,
+								Required:    true,
+							},
+							"pin": {
+								Description: "The gift card PIN.",
+								Type:        types.StringType,
+								Required:    true,
+							},
+						}),
+					},
+					"cash": {
+						Description: "Pay the driver in cash.",
+						Type:        types.BoolType,
 						Optional:    true,
 					},
 				}),
@@ -0,0 +1,61 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestRedact(t *testing.T) {
+	p := dominosProvider{
+		creditCard: &creditCardData{CreditCardNumber: types.Int64{Value: 4111111111111111}},
+		loyalty:    &loyaltySession{username: "pan@example.com", password: "hunter2&co"},
+	}
+	tr := newRecordingTransport(nil, recordingRecord, "", p.recordingSecrets())
+
+	tests := map[string]struct {
+		in   string
+		want string
+	}{
+		"card number anywhere": {
+			in:   `{"Number":"4111111111111111","Note":"card 4111111111111111"}`,
+			want: `{"Number":"REDACTED","Note":"card REDACTED"}`,
+		},
+		"fields by name": {
+			in:   `{"FirstName":"Pan","LastName":"Smith","Email":"pan@example.com","Phone":"5555555555","SecurityCode":"123","Expiration":"0130"}`,
+			want: `{"FirstName":"REDACTED","LastName":"REDACTED","Email":"REDACTED","Phone":"REDACTED","SecurityCode":"REDACTED","Expiration":"REDACTED"}`,
+		},
+		"short values elsewhere are left alone": {
+			in:   `{"FirstName":"Pan","Name":"Pan Pizza","Code":"123"}`,
+			want: `{"FirstName":"REDACTED","Name":"Pan Pizza","Code":"123"}`,
+		},
+		"login tokens": {
+			in:   `{"access_token":"abc.def","refresh_token":"ghi","token_type":"Bearer"}`,
+			want: `{"access_token":"REDACTED","refresh_token":"REDACTED","token_type":"Bearer"}`,
+		},
+		"tracker elements": {
+			in:   `<OrderStatus><StoreID>7940</StoreID><Phone>5555555555</Phone><DriverName>Sam</DriverName><DriverID xsi:nil="true" /><ManagerName>Alex</ManagerName><OrderStatus>Oven</OrderStatus></OrderStatus>`,
+			want: `<OrderStatus><StoreID>7940</StoreID><Phone>REDACTED</Phone><DriverName>REDACTED</DriverName><DriverID xsi:nil="true" /><ManagerName>REDACTED</ManagerName><OrderStatus>Oven</OrderStatus></OrderStatus>`,
+		},
+		"query parameters": {
+			in:   `https://trkweb.dominos.com/orderstorage/GetTrackerData?Phone=5555555555&lang=en`,
+			want: `https://trkweb.dominos.com/orderstorage/GetTrackerData?Phone=REDACTED&lang=en`,
+		},
+		"login form": {
+			in:   `grant_type=password&username=pan%40example.com&password=hunter2%26co&scope=customer`,
+			want: `grant_type=password&username=REDACTED&password=REDACTED&scope=customer`,
+		},
+		"parameter names inside other names": {
+			in:   `https://order.dominos.com/power/store-locator?type=Delivery&c=Seattle`,
+			want: `https://order.dominos.com/power/store-locator?type=Delivery&c=Seattle`,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := tr.redact(tt.in); got != tt.want {
+				t.Errorf("redact(%s)\n got: %s\nwant: %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -2,7 +2,15 @@ package provider
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
@@ -15,6 +23,7 @@ import (
 var _ provider.ResourceType = resourceOrderType{}
 var _ resource.Resource = resourceOrder{}
 var _ resource.ResourceWithImportState = resourceOrder{}
+var _ resource.ResourceWithModifyPlan = resourceOrder{}
 
 type resourceOrderType struct{}
 
@@ -26,37 +35,202 @@ This is it! This will order you your pizzas!
 
 As far as I know there is no way to cancel a dominos order programmatically, so if you made a mistake, you'll have to call the store.
 You should receive an email confirmation almost instantly, and that email will have the store's phone number in it.
+
+Orders placed by phone or on the website can be brought under Terraform with terraform import, using either store_id:order_id or the phone number the order was placed with (which imports the most recent order for that number).
+The status and when it was placed come from the tracker. The tracker doesn't say what was ordered, so item_codes and total_price are filled in from the order history of the provider's loyalty account, when it has one and the order was placed with it.
+Set item_codes, store_id and api_object in the configuration to match the imported order. Anything the import couldn't fill in is taken from the configuration on the next apply, without ordering again. After that first apply, changing what is ordered, including adding a tip or a reward, places a new order like it does for any other order.
 		`,
 		Attributes: map[string]tfsdk.Attribute{
 			"api_object": {
 				Description: "The computed json payload for the specified address.",
 				Required:    true,
 				Type:        types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					requiresNewOrder()},
 			},
 			"item_codes": {
-				Description: "An array of menu items to order.",
+				Description: "An array of menu items to order. Every code is checked against the store's menu (or menu_file) at plan time.",
 				Required:    true,
 				Type: types.ListType{
 					ElemType: types.StringType,
 				},
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					requiresNewOrder()},
 			},
 			"store_id": {
 				Description: "The ID of the store that the order is for.",
 				Required:    true,
 				Type:        types.Int64Type,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					requiresNewOrder()},
 			},
 			"price_only": {
 				Description: "DRY RUN: This will only display the total price of the order (and not actually order).",
 				Optional:    true,
 				Type:        types.BoolType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					requiresNewOrder()},
+			},
+			"idempotency_token": {
+				Description: "An optional token that is part of idempotency_key. Set it to something new to deliberately place the same order again within duplicate_window.",
+				Optional:    true,
+				Type:        types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					requiresNewOrder()},
+			},
+			"duplicate_window": {
+				Description: "How long an identical order is refused for after being placed, as a duration. Ex: '30m'. Default: '1h'.",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"idempotency_key": {
+				Description: "A key derived from the store, address, items and idempotency_token. Orders with the same key are only placed once per duplicate_window.",
+				Computed:    true,
+				Type:        types.StringType,
+			},
+			"tip_amount": {
+				Description: "A tip for the driver, in the market's currency. Conflicts with tip_percent.",
+				Optional:    true,
+				Type:        types.Float64Type,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					requiresNewOrder()},
+			},
+			"tip_percent": {
+				Description: "A tip for the driver, as a percentage of the food total before taxes and fees. Ex: 15. Conflicts with tip_amount.",
+				Optional:    true,
+				Type:        types.Float64Type,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					requiresNewOrder()},
+			},
+			"wait_until": {
+				Description: "Wait for the order to reach this stage in the tracker before finishing the apply, so other resources can depend on it. One of 'placed', 'out_for_delivery' or 'delivered'.",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"wait_timeout": {
+				Description: "How long to wait for wait_until, as a duration. Ex: '90m'. Default: '60m'.",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"customer": {
+				Description: "Who the order is for, when it isn't the person in the provider block. Each detail left unset falls back to the provider. Changing it after the order is placed only updates state.",
+				Optional:    true,
+				Attributes:  tfsdk.SingleNestedAttributes(customerAttributes()),
+			},
+			"payment_profile": {
+				Description: "The name of the provider payment_profile to pay with. Default: the provider's credit_card. Changing it after the order is placed only updates state.",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"redeem_reward": {
+				Description: "The code of a reward from the dominos_loyalty data source to add to the order, paid for with points from the provider's loyalty account. The reward is checked at plan time, and what it takes off is left out of the estimate checked against max_order_total, the budget and the approval threshold.",
+				Optional:    true,
+				Type:        types.StringType,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					requiresNewOrder()},
+			},
+			"menu_file": {
+				Description: "A local menu file saved with the export_file of dominos_menu. When set, item_codes are checked and the order's price is estimated against it at plan time instead of the store's menu, so planning doesn't reach Dominos unless redeem_reward is set. Placing the order still does.",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"on_destroy": {
+				Description: "What to do when the order is destroyed, since placed orders can't be cancelled through the API. 'forget' removes it from state, 'fail' refuses and tells you which store to call. Default: 'forget'.",
+				Optional:    true,
+				Type:        types.StringType,
+			},
+			"approval": {
+				Description: "An approval for this order from someone holding the provider's approval_secret. Required when the order is over the provider's approval_threshold.",
+				Optional:    true,
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"token": {
+						Description: "The approval token from a dominos_order_approval data source for the same store_id and item_codes.",
+						Type:        types.StringType,
+						Required:    true,
+						Sensitive:   true,
+					},
+				}),
 			},
 			"total_price": {
-				Description: "The computed total price of the order.",
+				Description: "The computed total price of the order, including the tip.",
 				Computed:    true,
 				PlanModifiers: tfsdk.AttributePlanModifiers{
 					resource.UseStateForUnknown()},
 				Type: types.NumberType,
 			},
+			"id": {
+				Description: "The ID of the order, as 'store_id:order_id'.",
+				Computed:    true,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown()},
+				Type: types.StringType,
+			},
+			"order_id": {
+				Description: "The order ID assigned by Dominos.",
+				Computed:    true,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown()},
+				Type: types.StringType,
+			},
+			"placed_at": {
+				Description: "When the order was placed, in RFC 3339 format. Empty for price_only orders.",
+				Computed:    true,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown()},
+				Type: types.StringType,
+			},
+			"estimated_wait_minutes": {
+				Description: "The estimated minutes until the order is ready, as a range. Ex: '20-30'.",
+				Computed:    true,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown()},
+				Type: types.StringType,
+			},
+			"status": {
+				Description: "The status of the order, refreshed from the Dominos tracker. 'Priced' for price_only orders.",
+				Computed:    true,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown()},
+				Type: types.StringType,
+			},
+			"price_breakdown": {
+				Description: "The breakdown of total_price, as priced by Dominos.",
+				Computed:    true,
+				PlanModifiers: tfsdk.AttributePlanModifiers{
+					resource.UseStateForUnknown()},
+				Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+					"menu": {
+						Description: "The menu price of the items.",
+						Type:        types.Float64Type,
+						Computed:    true,
+					},
+					"discount": {
+						Description: "The discount from coupons.",
+						Type:        types.Float64Type,
+						Computed:    true,
+					},
+					"surcharge": {
+						Description: "Any surcharges added by the store.",
+						Type:        types.Float64Type,
+						Computed:    true,
+					},
+					"delivery_fee": {
+						Description: "The delivery fee.",
+						Type:        types.Float64Type,
+						Computed:    true,
+					},
+					"tax": {
+						Description: "The tax on the order.",
+						Type:        types.Float64Type,
+						Computed:    true,
+					},
+					"tip": {
+						Description: "The tip for the driver.",
+						Type:        types.Float64Type,
+						Computed:    true,
+					},
+				}),
+			},
 		},
 	}, nil
 }
@@ -75,6 +249,181 @@ type resourceOrderData struct {
 	StoreID       types.Int64  `tfsdk:"store_id"`
 	PriceOnly     types.Bool   `tfsdk:"price_only"`
 	TotalPrice    types.Number `tfsdk:"total_price"`
+
+	IdempotencyToken types.String       `tfsdk:"idempotency_token"`
+	DuplicateWindow  types.String       `tfsdk:"duplicate_window"`
+	IdempotencyKey   types.String       `tfsdk:"idempotency_key"`
+	TipAmount        types.Float64      `tfsdk:"tip_amount"`
+	TipPercent       types.Float64      `tfsdk:"tip_percent"`
+	WaitUntil        types.String       `tfsdk:"wait_until"`
+	WaitTimeout      types.String       `tfsdk:"wait_timeout"`
+	OnDestroy        types.String       `tfsdk:"on_destroy"`
+	PaymentProfile   types.String       `tfsdk:"payment_profile"`
+	RedeemReward     types.String       `tfsdk:"redeem_reward"`
+	MenuFile         types.String       `tfsdk:"menu_file"`
+	Customer         *customerData      `tfsdk:"customer"`
+	Approval         *orderApprovalData `tfsdk:"approval"`
+
+	ID                   types.String `tfsdk:"id"`
+	OrderID              types.String `tfsdk:"order_id"`
+	PlacedAt             types.String `tfsdk:"placed_at"`
+	EstimatedWaitMinutes types.String `tfsdk:"estimated_wait_minutes"`
+	Status               types.String `tfsdk:"status"`
+	PriceBreakdown       types.Object `tfsdk:"price_breakdown"`
+}
+
+// Statuses for orders that never made it to the tracker.
+const (
+	orderStatusPriced = "Priced"
+	orderStatusPlaced = "Placed"
+)
+
+const (
+	onDestroyForget = "forget"
+	onDestroyFail   = "fail"
+)
+
+// placed reports whether the order was actually sent to the store.
+func (d resourceOrderData) placed() bool {
+	return d.OrderID.Value != "" && d.Status.Value != orderStatusPriced
+}
+
+// duplicateWindow parses duplicate_window, falling back to the default.
+func (d resourceOrderData) duplicateWindow() (time.Duration, error) {
+	if d.DuplicateWindow.Null || d.DuplicateWindow.Value == "" {
+		return defaultDuplicateWindow, nil
+	}
+	return time.ParseDuration(d.DuplicateWindow.Value)
+}
+
+// tipCents returns the tip for an order whose food costs foodCents.
+func (d resourceOrderData) tipCents(foodCents int64) int64 {
+	if !d.TipAmount.Null {
+		return int64(math.Round(d.TipAmount.Value * 100))
+	}
+	if !d.TipPercent.Null {
+		return int64(math.Round(float64(foodCents) * d.TipPercent.Value / 100))
+	}
+	return 0
+}
+
+// defaultWaitTimeout is how long to wait for wait_until when the resource
+// doesn't set wait_timeout.
+const defaultWaitTimeout = 60 * time.Minute
+
+// waitTimeout parses wait_timeout, falling back to the default.
+func (d resourceOrderData) waitTimeout() (time.Duration, error) {
+	if d.WaitTimeout.Null || d.WaitTimeout.Value == "" {
+		return defaultWaitTimeout, nil
+	}
+	return time.ParseDuration(d.WaitTimeout.Value)
+}
+
+// requiresNewOrder replaces the order whenever what is being ordered changes,
+// since a placed order can't be edited.
+func requiresNewOrder() tfsdk.AttributePlanModifier {
+	return requiresNewOrderModifier{}
+}
+
+type requiresNewOrderModifier struct{}
+
+func (m requiresNewOrderModifier) Description(ctx context.Context) string {
+	return "Changing this places a new order."
+}
+
+func (m requiresNewOrderModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m requiresNewOrderModifier) Modify(ctx context.Context, req tfsdk.ModifyAttributePlanRequest, resp *tfsdk.ModifyAttributePlanResponse) {
+	// Creating and destroying never replace
+	if req.State.Raw.IsNull() || req.Plan.Raw.IsNull() {
+		return
+	}
+
+	var state resourceOrderData
+	diags := req.State.Get(ctx, &state)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() || state.imported() {
+		return
+	}
+
+	if !sameOrderValue(req.AttributeState, req.AttributePlan) {
+		resp.RequiresReplace = true
+	}
+}
+
+// imported reports whether the order was imported and hasn't been applied
+// since. An import only knows the store and order ID, so filling in the rest
+// from config mustn't order again. Created orders always have item_codes and
+// an idempotency_key, imported ones get them on their first apply.
+func (d resourceOrderData) imported() bool {
+	return d.ItemCodes.Null || d.IdempotencyKey.Null
+}
+
+// sameOrderValue compares an attribute before and after a change, treating
+// an unset bool as false, so writing out price_only = false doesn't order
+// again.
+func sameOrderValue(state, plan attr.Value) bool {
+	if b, ok := state.(types.Bool); ok && b.Null {
+		state = types.Bool{Value: false}
+	}
+	if b, ok := plan.(types.Bool); ok && b.Null {
+		plan = types.Bool{Value: false}
+	}
+	return state.Equal(plan)
+}
+
+// replacesOrder reports whether going from state to plan changes any
+// attribute with requiresNewOrder, so applying it places a new order. Keep
+// it in step with the schema.
+func replacesOrder(state, plan resourceOrderData) bool {
+	if state.imported() {
+		return false
+	}
+
+	attrs := []struct{ state, plan attr.Value }{
+		{state.AddressAPIObj, plan.AddressAPIObj},
+		{state.ItemCodes, plan.ItemCodes},
+		{state.StoreID, plan.StoreID},
+		{state.PriceOnly, plan.PriceOnly},
+		{state.IdempotencyToken, plan.IdempotencyToken},
+		{state.TipAmount, plan.TipAmount},
+		{state.TipPercent, plan.TipPercent},
+		{state.RedeemReward, plan.RedeemReward},
+	}
+	for _, a := range attrs {
+		if !sameOrderValue(a.state, a.plan) {
+			return true
+		}
+	}
+	return false
+}
+
+var priceBreakdownAttrTypes = map[string]attr.Type{
+	"menu":         types.Float64Type,
+	"discount":     types.Float64Type,
+	"surcharge":    types.Float64Type,
+	"delivery_fee": types.Float64Type,
+	"tax":          types.Float64Type,
+	"tip":          types.Float64Type,
+}
+
+func newPriceBreakdown(priced orderResponse, tipCents int64) types.Object {
+	deliveryFee, _ := priced.Order.AmountsBreakdown.DeliveryFee.Float64()
+
+	return types.Object{
+		AttrTypes: priceBreakdownAttrTypes,
+		Attrs: map[string]attr.Value{
+			"menu":         types.Float64{Value: priced.Order.Amounts.Menu},
+			"discount":     types.Float64{Value: priced.Order.Amounts.Discount},
+			"surcharge":    types.Float64{Value: priced.Order.Amounts.Surcharge},
+			"delivery_fee": types.Float64{Value: deliveryFee},
+			"tax":          types.Float64{Value: priced.Order.Amounts.Tax},
+			"tip":          types.Float64{Value: float64(tipCents) / 100},
+		},
+	}
 }
 
 type resourceOrder struct {
@@ -84,13 +433,213 @@ type resourceOrder struct {
 func (r resourceOrder) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data resourceOrderData
 
-	diags := req.Config.Get(ctx, &data)
+	diags := req.Plan.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var itemCodes []string
+	diags = data.ItemCodes.ElementsAs(ctx, &itemCodes, false)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.IdempotencyKey = types.String{Value: idempotencyKey(data.StoreID.Value, data.AddressAPIObj.Value, itemCodes, data.IdempotencyToken.Value)}
+
+	c := r.provider.customer(data.Customer)
+	if missing := c.missing(); len(missing) > 0 && !data.PriceOnly.Value {
+		resp.Diagnostics.AddAttributeError(path.Root("customer"), "Missing customer details", missingCustomerDetail(missing))
+		return
+	}
+
+	// The plan skips the limits when it doesn't know the order yet, e.g. when
+	// the address comes from another resource, so check them again here
+	diags = r.provider.checkItemLimit(itemCodes)
 	resp.Diagnostics.Append(diags...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
+	o := r.provider.newOrder(data.StoreID.Value, data.AddressAPIObj.Value, itemCodes, c)
+
+	orderCtx := r.provider.apiContext(ctx, subsystemOrder)
+	if data.RedeemReward.Value != "" {
+		var err error
+		orderCtx, err = r.provider.redeemReward(orderCtx, &o, data.RedeemReward.Value)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("redeem_reward"), "Cannot redeem reward", err.Error())
+			return
+		}
+	}
+
+	// Validating first reports problems with individual items, which
+	// price-order tends to fold into a single error for the whole order
+	_, err := validateOrder(orderCtx, r.provider.market.APIHost()+"/power/validate-order", o, r.provider.client)
+	if err != nil {
+		resp.Diagnostics.Append(orderErrorDiagnostics("Invalid order", err, len(itemCodes))...)
+		return
+	}
+
+	priced, err := priceOrder(orderCtx, r.provider.market.APIHost()+"/power/price-order", o, r.provider.client)
+	if err != nil {
+		resp.Diagnostics.Append(orderErrorDiagnostics("Cannot price order", err, len(itemCodes))...)
+		return
+	}
+
+	tipCents := data.tipCents(int64(math.Round(priced.Order.Amounts.Menu * 100)))
+	totalWithTip := priced.Order.Amounts.Customer + float64(tipCents)/100
+	pricedCents := int64(math.Round(totalWithTip * 100))
+
+	if r.provider.maxOrderTotalCents > 0 && pricedCents > r.provider.maxOrderTotalCents {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("item_codes"),
+			"Order exceeds max_order_total",
+			fmt.Sprintf("This order is priced at %s including taxes, fees and the tip, which is more than the provider's max_order_total of %s.", r.provider.formatCents(pricedCents), r.provider.formatCents(r.provider.maxOrderTotalCents)),
+		)
+		return
+	}
+
+	data.TotalPrice = types.Number{Value: big.NewFloat(totalWithTip)}
+	data.PriceBreakdown = newPriceBreakdown(priced, tipCents)
+	data.OrderID = types.String{Value: priced.Order.OrderID}
+	data.EstimatedWaitMinutes = types.String{Value: priced.Order.EstimatedWaitMinutes}
+	data.PlacedAt = types.String{Null: true}
+	data.Status = types.String{Value: orderStatusPriced}
+
+	if !data.PriceOnly.Value {
+		// Check the approval again right before ordering, in case the menu
+		// changed between plan and apply
+		if r.provider.approvalThresholdCents > 0 || data.Approval != nil {
+			totalCents, err := r.provider.menuTotalCents(ctx, data.StoreID.Value, types.String{Null: true}, itemCodes)
+			if err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("item_codes"), "Cannot price order", err.Error())
+				return
+			}
+			discountCents := int64(math.Round(priced.Order.Amounts.Discount * 100))
+			if r.provider.needsApproval(data.Approval, totalCents-discountCents) {
+				err = r.provider.checkApproval(data.Approval, data.StoreID.Value, itemCodes, totalCents)
+				if err != nil {
+					resp.Diagnostics.AddAttributeError(path.Root("approval"), "Order not approved", err.Error())
+					return
+				}
+			}
+		}
+
+		// Amount has to match the priced total. The tip goes on top of it in
+		// TipAmount, and is charged along with it.
+		payment, err := r.provider.payment(data.PaymentProfile.Value, priced.Order.Amounts.Customer)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("payment_profile"), "Cannot place order", err.Error())
+			return
+		}
+		payment.TipAmount = float64(tipCents) / 100
+		o.OrderID = priced.Order.OrderID
+		o.Payments = []orderPayment{payment}
+
+		// The plan only had menu prices to go on, so check the budget again
+		// now that taxes, fees and the tip are known. The ledger stays locked
+		// until the order is in it, so orders created in parallel can't both
+		// spend what is left.
+		unlockLedger := func() {}
+		if r.provider.budget != nil {
+			unlockLedger = lockLedger()
+			defer unlockLedger()
+
+			remaining, err := r.provider.budgetRemaining(time.Now())
+			if err != nil {
+				resp.Diagnostics.AddError("Cannot read budget ledger", err.Error())
+				return
+			}
+			if pricedCents > remaining {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("item_codes"),
+					"Order exceeds budget",
+					fmt.Sprintf("This order is priced at %s including taxes, fees and the tip, but only %s of the %s %s budget is left.", r.provider.formatCents(pricedCents), r.provider.formatCents(remaining), r.provider.budget.period, r.provider.formatCents(r.provider.budget.amountCents)),
+				)
+				return
+			}
+		}
+
+		window, err := data.duplicateWindow()
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("duplicate_window"), "Invalid duplicate_window", err.Error())
+			return
+		}
+
+		// Record the attempt before ordering, so that if we never hear back
+		// from Dominos a retried apply still won't order twice
+		key := data.IdempotencyKey.Value
+		startedAt := time.Now()
+		err = beginIdempotentOrder(r.provider.idempotencyPath, key, window, startedAt)
+		if err != nil {
+			resp.Diagnostics.AddError("Cannot place order", err.Error())
+			return
+		}
+
+		placed, err := placeOrder(orderCtx, r.provider.market.APIHost()+"/power/place-order", o, r.provider.client)
+		if err != nil {
+			// Dominos definitely didn't take the order, so it is safe to retry
+			if errors.Is(err, errOrderRejected) {
+				_ = abandonIdempotentOrder(r.provider.idempotencyPath, key, startedAt)
+			}
+			resp.Diagnostics.Append(orderErrorDiagnostics("Cannot place order", err, len(itemCodes))...)
+			return
+		}
+
+		err = finishIdempotentOrder(r.provider.idempotencyPath, key, startedAt, placed.Order.OrderID)
+		if err != nil {
+			resp.Diagnostics.AddWarning("Cannot record placed order", fmt.Sprintf("The order was placed, but could not be recorded in %s: %v", r.provider.idempotencyPath, err))
+		}
+
+		data.PlacedAt = types.String{Value: time.Now().UTC().Format(time.RFC3339)}
+		data.Status = types.String{Value: orderStatusPlaced}
+		if placed.Order.OrderID != "" {
+			data.OrderID = types.String{Value: placed.Order.OrderID}
+		}
+		if placed.Order.EstimatedWaitMinutes != "" {
+			data.EstimatedWaitMinutes = types.String{Value: placed.Order.EstimatedWaitMinutes}
+		}
+
+		if r.provider.budget != nil {
+			err = appendLedger(r.provider.budget.ledgerPath, ledgerEntry{
+				PlacedAt:   time.Now(),
+				StoreID:    data.StoreID.Value,
+				OrderID:    placed.Order.OrderID,
+				TotalCents: pricedCents,
+				Currency:   r.provider.market.Currency(),
+			})
+			// The order has already been placed, so failing here would only
+			// lose track of it in state
+			if err != nil {
+				resp.Diagnostics.AddWarning("Cannot record order in budget ledger", fmt.Sprintf("The order was placed, but could not be added to %s: %v", r.provider.budget.ledgerPath, err))
+			}
+		}
+		unlockLedger()
+	}
+
+	data.ID = types.String{Value: fmt.Sprintf("%d:%s", data.StoreID.Value, data.OrderID.Value)}
+
+	if data.placed() && !data.WaitUntil.Null {
+		timeout, _ := data.waitTimeout()
+		status, err := waitForOrderStage(r.provider.apiContext(ctx, subsystemTracker), r.provider.trackerURL(data.StoreID.Value, data.OrderID.Value), orderStages[data.WaitUntil.Value], timeout, r.provider.client)
+		if status != "" {
+			data.Status = types.String{Value: status}
+		}
+		// The order has been placed either way, so it has to be saved to
+		// state even if waiting failed
+		if err != nil {
+			resp.Diagnostics.AddError(
+				"Order did not reach wait_until",
+				fmt.Sprintf("Order %s was placed and saved to state, but %v. Run terraform untaint on it rather than letting it be replaced, or you'll get a second order.", data.OrderID.Value, err),
+			)
+		}
+	}
+
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
@@ -105,10 +654,48 @@ func (r resourceOrder) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
+	// Only placed orders show up in the tracker
+	if data.Status.Value != orderStatusPriced && data.OrderID.Value != "" {
+		tracked, err := getTrackedOrder(r.provider.apiContext(ctx, subsystemTracker), r.provider.trackerURL(data.StoreID.Value, data.OrderID.Value), r.provider.client)
+		if err != nil {
+			resp.Diagnostics.AddWarning("Cannot refresh order status", fmt.Sprintf("The status of order %s could not be fetched from the tracker: %v", data.OrderID.Value, err))
+		} else {
+			if tracked.OrderStatus != "" {
+				data.Status = types.String{Value: tracked.OrderStatus}
+			}
+			// Imported orders only learn when they were placed from the tracker
+			if data.PlacedAt.Null && tracked.StartTime != "" {
+				data.PlacedAt = types.String{Value: tracked.StartTime}
+			}
+		}
+	}
+
+	// The tracker doesn't say what was ordered, so imported orders fill in
+	// their items and price from the rewards account's order history
+	if data.ItemCodes.Null && data.OrderID.Value != "" && r.provider.loyalty != nil {
+		past, ok, err := r.provider.findPastOrder(r.provider.apiContext(ctx, subsystemLoyalty), data.StoreID.Value, data.OrderID.Value)
+		switch {
+		case err != nil:
+			resp.Diagnostics.AddWarning("Cannot look up order", fmt.Sprintf("The items in order %s could not be fetched from the order history: %v", data.OrderID.Value, err))
+		case !ok:
+			resp.Diagnostics.AddWarning("Cannot look up order", fmt.Sprintf("Order %s isn't in the last %d orders of the provider's loyalty account, so its items and price can't be filled in.", data.OrderID.Value, orderHistoryLimit))
+		default:
+			codes := past.itemCodes()
+			elems := make([]attr.Value, 0, len(codes))
+			for _, code := range codes {
+				elems = append(elems, types.String{Value: code})
+			}
+			data.ItemCodes = types.List{ElemType: types.StringType, Elems: elems}
+			data.TotalPrice = types.Number{Value: big.NewFloat(past.Order.Amounts.Customer)}
+		}
+	}
+
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
 
+// Update only ever changes settings that don't affect the order itself, like
+// on_destroy. Anything else replaces the order.
 func (r resourceOrder) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
 	var data resourceOrderData
 
@@ -119,10 +706,24 @@ func (r resourceOrder) Update(ctx context.Context, req resource.UpdateRequest, r
 		return
 	}
 
+	if data.IdempotencyKey.Unknown {
+		var itemCodes []string
+		diags = data.ItemCodes.ElementsAs(ctx, &itemCodes, false)
+		resp.Diagnostics.Append(diags...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		data.IdempotencyKey = types.String{Value: idempotencyKey(data.StoreID.Value, data.AddressAPIObj.Value, itemCodes, data.IdempotencyToken.Value)}
+	}
+
 	diags = resp.State.Set(ctx, &data)
 	resp.Diagnostics.Append(diags...)
 }
 
+// Delete can't cancel the order, so it either forgets about it or refuses,
+// depending on on_destroy.
 func (r resourceOrder) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 	var data resourceOrderData
 
@@ -132,8 +733,402 @@ func (r resourceOrder) Delete(ctx context.Context, req resource.DeleteRequest, r
 	if resp.Diagnostics.HasError() {
 		return
 	}
+
+	if data.placed() && data.OnDestroy.Value == onDestroyFail {
+		resp.Diagnostics.AddError("Order cannot be cancelled", r.cancelInstructions(ctx, data))
+	}
+}
+
+func (r resourceOrder) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	var state resourceOrderData
+	if !req.State.Raw.IsNull() {
+		diags := req.State.Get(ctx, &state)
+		resp.Diagnostics.Append(diags...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	if req.Plan.Raw.IsNull() {
+		if !state.placed() {
+			return
+		}
+		if state.OnDestroy.Value == onDestroyFail {
+			resp.Diagnostics.AddError("Order cannot be cancelled", r.cancelInstructions(ctx, state))
+			return
+		}
+		resp.Diagnostics.AddWarning(
+			"Destroying does not cancel the order",
+			fmt.Sprintf("Dominos orders can't be cancelled through the API, so destroying order %s only removes it from state. %s", state.OrderID.Value, r.cancelInstructions(ctx, state)),
+		)
+		return
+	}
+
+	var data resourceOrderData
+
+	diags := req.Plan.Get(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if !data.OnDestroy.Null && data.OnDestroy.Value != onDestroyForget && data.OnDestroy.Value != onDestroyFail {
+		resp.Diagnostics.AddAttributeError(path.Root("on_destroy"), "Invalid on_destroy", fmt.Sprintf("on_destroy must be '%s' or '%s', got %q.", onDestroyForget, onDestroyFail, data.OnDestroy.Value))
+		return
+	}
+
+	if _, err := data.duplicateWindow(); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("duplicate_window"), "Invalid duplicate_window", err.Error())
+		return
+	}
+
+	if _, ok := orderStages[data.WaitUntil.Value]; !data.WaitUntil.Null && !data.WaitUntil.Unknown && !ok {
+		resp.Diagnostics.AddAttributeError(path.Root("wait_until"), "Invalid wait_until", fmt.Sprintf("wait_until must be 'placed', 'out_for_delivery' or 'delivered', got %q.", data.WaitUntil.Value))
+		return
+	}
+
+	if !data.TipAmount.Null && !data.TipPercent.Null {
+		resp.Diagnostics.AddAttributeError(path.Root("tip_percent"), "Conflicting tip", "Only one of tip_amount and tip_percent can be set.")
+		return
+	}
+	if data.TipAmount.Value < 0 {
+		resp.Diagnostics.AddAttributeError(path.Root("tip_amount"), "Invalid tip_amount", "The tip can't be negative.")
+		return
+	}
+	if data.TipPercent.Value < 0 || data.TipPercent.Value > 100 {
+		resp.Diagnostics.AddAttributeError(path.Root("tip_percent"), "Invalid tip_percent", "The tip percentage must be between 0 and 100.")
+		return
+	}
+
+	if _, err := data.waitTimeout(); err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("wait_timeout"), "Invalid wait_timeout", err.Error())
+		return
+	}
+
+	diags = r.provider.checkCustomer(data.Customer)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The customer, payment and loyalty account are only used to place the
+	// order, so a change to the provider block mustn't fail the plans of
+	// orders that were placed already
+	createsOrder := req.State.Raw.IsNull() || replacesOrder(state, data)
+
+	if createsOrder && !data.PriceOnly.Value && !customerUnknown(data.Customer) {
+		if missing := r.provider.customer(data.Customer).missing(); len(missing) > 0 {
+			resp.Diagnostics.AddAttributeError(path.Root("customer"), "Missing customer details", missingCustomerDetail(missing))
+			return
+		}
+	}
+
+	if createsOrder && !data.PriceOnly.Value && !data.PaymentProfile.Unknown {
+		if err := r.provider.checkPaymentProfile(data.PaymentProfile.Value); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("payment_profile"), "Invalid payment_profile", err.Error())
+			return
+		}
+	}
+
+	if createsOrder && data.RedeemReward.Value != "" && r.provider.loyalty == nil {
+		resp.Diagnostics.AddAttributeError(path.Root("redeem_reward"), "Missing loyalty account", "The provider needs a loyalty block to redeem rewards.")
+		return
+	}
+
+	// Neither of these replaces the order, so changing them after it's placed
+	// only changes state
+	if state.placed() && !createsOrder {
+		if !customerUnknown(data.Customer) && !state.Customer.equal(data.Customer) {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("customer"),
+				"Customer change doesn't reach the placed order",
+				fmt.Sprintf("Order %s has already been placed, and Dominos doesn't let its customer details be changed, so this change only updates state. Call the store if the order needs to go to someone else.", state.OrderID.Value),
+			)
+		}
+		if !data.PaymentProfile.Unknown && !state.PaymentProfile.Equal(data.PaymentProfile) {
+			resp.Diagnostics.AddAttributeWarning(
+				path.Root("payment_profile"),
+				"Payment change doesn't reach the placed order",
+				fmt.Sprintf("Order %s has already been paid for, so this change only updates state. The card already charged stays charged.", state.OrderID.Value),
+			)
+		}
+	}
+
+	// A list of codes can be known while some of its codes aren't, e.g. when
+	// they come from a dominos_menu_item that hasn't been read yet
+	if data.ItemCodes.Unknown || data.StoreID.Unknown || !elementsKnown(data.ItemCodes) || data.MenuFile.Unknown {
+		return
+	}
+
+	var itemCodes []string
+	diags = data.ItemCodes.ElementsAs(ctx, &itemCodes, false)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	key := ""
+	if !data.AddressAPIObj.Unknown && !data.IdempotencyToken.Unknown {
+		key = idempotencyKey(data.StoreID.Value, data.AddressAPIObj.Value, itemCodes, data.IdempotencyToken.Value)
+		diags = resp.Plan.SetAttribute(ctx, path.Root("idempotency_key"), key)
+		resp.Diagnostics.Append(diags...)
+	}
+
+	if !req.State.Raw.IsNull() {
+		// Only re-check when the change places a new order
+		if !createsOrder {
+			return
+		}
+
+		if state.placed() && !state.ItemCodes.Null {
+			resp.Diagnostics.AddWarning(
+				"Changing a placed order orders again",
+				fmt.Sprintf("Order %s has already been placed and can't be edited, so this change places a new order. The existing order is not cancelled. %s", state.OrderID.Value, r.cancelInstructions(ctx, state)),
+			)
+		}
+	}
+
+	// Catch typos and codes from other stores now, rather than at apply
+	diags = r.provider.checkItemCodes(ctx, data.StoreID.Value, data.MenuFile, itemCodes)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if key != "" && !data.PriceOnly.Value {
+		l, err := readIdempotencyLog(r.provider.idempotencyPath)
+		if err != nil {
+			resp.Diagnostics.AddError("Cannot read idempotency file", err.Error())
+			return
+		}
+
+		window, _ := data.duplicateWindow()
+		if rec, ok := l.find(key, window, time.Now()); ok {
+			resp.Diagnostics.AddAttributeError(path.Root("idempotency_token"), "Duplicate order", duplicateOrderError(rec).Error())
+			return
+		}
+	}
+
+	diags = r.provider.checkItemLimit(itemCodes)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.RedeemReward.Unknown {
+		return
+	}
+
+	// Check the reward now, rather than after the order has been validated
+	var o order
+	var rewardCtx context.Context
+	if data.RedeemReward.Value != "" {
+		var err error
+		o = r.provider.newOrder(data.StoreID.Value, data.AddressAPIObj.Value, itemCodes, r.provider.customer(data.Customer))
+		rewardCtx, err = r.provider.redeemReward(r.provider.apiContext(ctx, subsystemOrder), &o, data.RedeemReward.Value)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("redeem_reward"), "Cannot redeem reward", err.Error())
+			return
+		}
+	}
+
+	if r.provider.maxOrderTotalCents == 0 && r.provider.approvalThresholdCents == 0 && data.Approval == nil && r.provider.budget == nil {
+		return
+	}
+
+	totalCents, err := r.provider.menuTotalCents(ctx, data.StoreID.Value, data.MenuFile, itemCodes)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("item_codes"), "Cannot price order", err.Error())
+		return
+	}
+
+	// Menu prices don't know what a reward takes off, so price the order
+	// with it. Without an address that has to wait for apply, which checks
+	// the budget and approval again.
+	var discountCents int64
+	if data.RedeemReward.Value != "" {
+		if data.AddressAPIObj.Unknown {
+			return
+		}
+		priced, err := priceOrder(rewardCtx, r.provider.market.APIHost()+"/power/price-order", o, r.provider.client)
+		if err != nil {
+			resp.Diagnostics.Append(orderErrorDiagnostics("Cannot price order", err, len(itemCodes))...)
+			return
+		}
+		discountCents = int64(math.Round(priced.Order.Amounts.Discount * 100))
+	}
+
+	// Spending limits include the tip, approvals only cover the food. Tips
+	// are on the menu price, before the reward.
+	foodCents := totalCents - discountCents
+	spendCents := foodCents + data.tipCents(totalCents)
+	estimate := "from menu prices, before taxes and fees"
+	if discountCents > 0 {
+		estimate = "from menu prices less the reward, before taxes and fees"
+	}
+
+	if r.provider.maxOrderTotalCents > 0 && spendCents > r.provider.maxOrderTotalCents {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("item_codes"),
+			"Order exceeds max_order_total",
+			fmt.Sprintf("This order is estimated at %s %s, which is more than the provider's max_order_total of %s.", r.provider.formatCents(spendCents), estimate, r.provider.formatCents(r.provider.maxOrderTotalCents)),
+		)
+		return
+	}
+
+	if data.PriceOnly.Value {
+		return
+	}
+
+	if r.provider.budget != nil {
+		remaining, err := r.provider.budgetRemaining(time.Now())
+		if err != nil {
+			resp.Diagnostics.AddError("Cannot read budget ledger", err.Error())
+			return
+		}
+		if spendCents > remaining {
+			resp.Diagnostics.AddAttributeError(
+				path.Root("item_codes"),
+				"Order exceeds budget",
+				fmt.Sprintf("This order is estimated at %s %s, but only %s of the %s %s budget is left.", r.provider.formatCents(spendCents), estimate, r.provider.formatCents(remaining), r.provider.budget.period, r.provider.formatCents(r.provider.budget.amountCents)),
+			)
+			return
+		}
+	}
+
+	// Approval tokens are signed over menu prices, the same as the
+	// dominos_order_approval data source works them out
+	if r.provider.needsApproval(data.Approval, foodCents) {
+		err = r.provider.checkApproval(data.Approval, data.StoreID.Value, itemCodes, totalCents)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("approval"), "Order not approved", err.Error())
+		}
+	}
 }
 
+// ImportState accepts either 'store_id:order_id', or a phone number to import
+// the most recent order placed with it. Everything else is filled in from the
+// tracker when the order is read.
 func (r resourceOrder) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	var storeID int64
+	var orderID string
+
+	if parts := strings.SplitN(req.ID, ":", 2); len(parts) == 2 {
+		id, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil || parts[1] == "" {
+			resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("Expected 'store_id:order_id' or a phone number, got %q.", req.ID))
+			return
+		}
+		storeID, orderID = id, parts[1]
+	} else {
+		phone, err := r.provider.market.NormalizePhone(req.ID)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("Expected 'store_id:order_id' or a phone number, but %v.", err))
+			return
+		}
+
+		// The phone number needn't be the provider's, so mask it as well
+		trackerCtx := maskLogValues(r.provider.apiContext(ctx, subsystemTracker), phone)
+		orders, err := getTrackedOrders(trackerCtx, r.provider.trackerPhoneURL(phone), r.provider.client)
+		if err != nil {
+			resp.Diagnostics.AddError("Cannot look up orders", fmt.Sprintf("The orders for phone number %s could not be fetched from the tracker: %v", req.ID, err))
+			return
+		}
+		if len(orders) == 0 {
+			resp.Diagnostics.AddError("No orders found", fmt.Sprintf("The tracker has no recent orders for phone number %s.", req.ID))
+			return
+		}
+
+		latest := mostRecentOrder(orders)
+		storeID, err = strconv.ParseInt(latest.StoreID, 10, 64)
+		if err != nil {
+			resp.Diagnostics.AddError("Cannot import order", fmt.Sprintf("The tracker returned an invalid store ID %q.", latest.StoreID))
+			return
+		}
+		orderID = latest.OrderID
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), fmt.Sprintf("%d:%s", storeID, orderID))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("store_id"), storeID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("order_id"), orderID)...)
+}
+
+// elementsKnown reports whether every element of a list is known.
+func elementsKnown(l types.List) bool {
+	for _, elem := range l.Elems {
+		if elem.IsUnknown() {
+			return false
+		}
+	}
+	return true
+}
+
+// menuTotalCents prices an order from the store's menu, or menuFile if it is
+// set. This is the price before taxes, fees and coupons, but unlike pricing
+// through the order API it only needs a store ID, so it can be done at plan
+// time.
+func (p dominosProvider) menuTotalCents(ctx context.Context, storeID int64, menuFile types.String, itemCodes []string) (int64, error) {
+	body, err := p.orderMenuBody(ctx, storeID, menuFile)
+	if err != nil {
+		return 0, err
+	}
+	menuitems, err := parseMenuItems(body)
+	if err != nil {
+		return 0, fmt.Errorf("cannot read menu: %w", err)
+	}
+
+	prices := make(map[string]int64, len(menuitems))
+	for i := range menuitems {
+		prices[menuitems[i].Code] = menuitems[i].PriceCents
+	}
+
+	var total int64
+	for _, code := range itemCodes {
+		price, ok := prices[code]
+		if !ok {
+			return 0, fmt.Errorf("item code %q is not on the menu for store %d", code, storeID)
+		}
+		total += price
+	}
+	return total, nil
+}
+
+// checkItemLimit returns an error if an order has more items than the
+// provider's max_items_per_order.
+func (p dominosProvider) checkItemLimit(itemCodes []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if p.maxItemsPerOrder > 0 && int64(len(itemCodes)) > p.maxItemsPerOrder {
+		diags.AddAttributeError(
+			path.Root("item_codes"),
+			"Order exceeds max_items_per_order",
+			fmt.Sprintf("This order has %d items, but the provider only allows %d per order. Double check item_codes isn't being fed the whole menu.", len(itemCodes), p.maxItemsPerOrder),
+		)
+	}
+	return diags
+}
+
+// formatCents renders an amount of cents in the market's currency. Ex: '12.99 USD'.
+func (p dominosProvider) formatCents(cents int64) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d %s", sign, cents/100, cents%100, p.market.Currency())
+}
+
+// cancelInstructions tells the user how to cancel an order by hand, including
+// the store's phone number when it can be looked up.
+func (r resourceOrder) cancelInstructions(ctx context.Context, data resourceOrderData) string {
+	profile, err := getStoreProfile(r.provider.apiContext(ctx, subsystemLocator), fmt.Sprintf("%s/power/store/%d/profile", r.provider.market.APIHost(), data.StoreID.Value), r.provider.client)
+	if err != nil || profile.Phone == "" {
+		return fmt.Sprintf("To cancel order %s, call store %d. Its phone number is in the order confirmation email.", data.OrderID.Value, data.StoreID.Value)
+	}
+	return fmt.Sprintf("To cancel order %s, call store %d at %s.", data.OrderID.Value, data.StoreID.Value, profile.Phone)
 }
@@ -0,0 +1,108 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestFormatCents(t *testing.T) {
+	tests := map[int64]string{
+		0:       "0.00 USD",
+		5:       "0.05 USD",
+		99:      "0.99 USD",
+		1299:    "12.99 USD",
+		100000:  "1000.00 USD",
+		-5:      "-0.05 USD",
+		-1299:   "-12.99 USD",
+		-100000: "-1000.00 USD",
+	}
+
+	m, _ := lookupMarket("US")
+	p := dominosProvider{market: m}
+	for cents, want := range tests {
+		if got := p.formatCents(cents); got != want {
+			t.Errorf("formatCents(%d) = %q, want %q", cents, got, want)
+		}
+	}
+}
+
+func TestCheckItemLimit(t *testing.T) {
+	tests := []struct {
+		limit   int64
+		items   int
+		wantErr bool
+	}{
+		{limit: 0, items: 186},
+		{limit: 10, items: 9},
+		{limit: 10, items: 10},
+		{limit: 10, items: 11, wantErr: true},
+		{limit: 10, items: 186, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		p := dominosProvider{maxItemsPerOrder: tt.limit}
+		diags := p.checkItemLimit(make([]string, tt.items))
+		if diags.HasError() != tt.wantErr {
+			t.Errorf("%d items with a limit of %d: got errors %v, want %v", tt.items, tt.limit, diags, tt.wantErr)
+		}
+	}
+}
+
+func TestReplacesOrder(t *testing.T) {
+	placed := func() resourceOrderData {
+		return resourceOrderData{
+			AddressAPIObj:    types.String{Value: `{"Street":"1 Main St"}`},
+			ItemCodes:        types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "14SCREEN"}}},
+			StoreID:          types.Int64{Value: 7940},
+			PriceOnly:        types.Bool{Null: true},
+			IdempotencyToken: types.String{Null: true},
+			IdempotencyKey:   types.String{Value: "abc123"},
+			TipAmount:        types.Float64{Null: true},
+			TipPercent:       types.Float64{Null: true},
+			RedeemReward:     types.String{Null: true},
+			OnDestroy:        types.String{Null: true},
+		}
+	}
+	imported := func() resourceOrderData {
+		d := placed()
+		d.ItemCodes = types.List{ElemType: types.StringType, Null: true}
+		d.IdempotencyKey = types.String{Null: true}
+		return d
+	}
+
+	tests := map[string]struct {
+		state  resourceOrderData
+		modify func(*resourceOrderData)
+		want   bool
+	}{
+		"no change":                      {state: placed(), modify: func(d *resourceOrderData) {}},
+		"on_destroy":                     {state: placed(), modify: func(d *resourceOrderData) { d.OnDestroy = types.String{Value: onDestroyFail} }},
+		"price_only = false written out": {state: placed(), modify: func(d *resourceOrderData) { d.PriceOnly = types.Bool{Value: false} }},
+		"items":                          {state: placed(), modify: func(d *resourceOrderData) { d.ItemCodes = types.List{ElemType: types.StringType} }, want: true},
+		"store":                          {state: placed(), modify: func(d *resourceOrderData) { d.StoreID = types.Int64{Value: 7941} }, want: true},
+		"adding a tip":                   {state: placed(), modify: func(d *resourceOrderData) { d.TipAmount = types.Float64{Value: 5} }, want: true},
+		"adding a tip percentage":        {state: placed(), modify: func(d *resourceOrderData) { d.TipPercent = types.Float64{Value: 15} }, want: true},
+		"adding a reward":                {state: placed(), modify: func(d *resourceOrderData) { d.RedeemReward = types.String{Value: "8155"} }, want: true},
+		"first idempotency_token":        {state: placed(), modify: func(d *resourceOrderData) { d.IdempotencyToken = types.String{Value: "again"} }, want: true},
+		"price_only":                     {state: placed(), modify: func(d *resourceOrderData) { d.PriceOnly = types.Bool{Value: true} }, want: true},
+		"imported, filled in from config": {
+			state: imported(),
+			modify: func(d *resourceOrderData) {
+				d.ItemCodes = types.List{ElemType: types.StringType, Elems: []attr.Value{types.String{Value: "14SCREEN"}}}
+				d.TipAmount = types.Float64{Value: 5}
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			plan := tt.state
+			tt.modify(&plan)
+			if got := replacesOrder(tt.state, plan); got != tt.want {
+				t.Errorf("replacesOrder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
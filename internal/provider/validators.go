@@ -0,0 +1,60 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// anyMarketValidator checks a string attribute is valid in at least one
+// market. The provider's market isn't known when the schema is validated, so
+// the check against the configured market happens when the data is read.
+type anyMarketValidator struct {
+	what      string
+	normalize func(m market, v string) (string, error)
+}
+
+var _ tfsdk.AttributeValidator = anyMarketValidator{}
+
+func postalCodeValidator() anyMarketValidator {
+	return anyMarketValidator{
+		what:      "postal code",
+		normalize: func(m market, v string) (string, error) { return m.NormalizePostalCode(v) },
+	}
+}
+
+func regionValidator() anyMarketValidator {
+	return anyMarketValidator{
+		what:      "state or province code",
+		normalize: func(m market, v string) (string, error) { return m.NormalizeRegion(v) },
+	}
+}
+
+func (v anyMarketValidator) Description(ctx context.Context) string {
+	return fmt.Sprintf("value must be a %s in one of: %s", v.what, strings.Join(marketCodes(), ", "))
+}
+
+func (v anyMarketValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v anyMarketValidator) Validate(ctx context.Context, req tfsdk.ValidateAttributeRequest, resp *tfsdk.ValidateAttributeResponse) {
+	value, ok := req.AttributeConfig.(types.String)
+	if !ok || value.Null || value.Unknown {
+		return
+	}
+
+	for _, code := range marketCodes() {
+		if _, err := v.normalize(markets[code], value.Value); err == nil {
+			return
+		}
+	}
+	resp.Diagnostics.AddAttributeError(
+		req.AttributePath,
+		fmt.Sprintf("Invalid %s", v.what),
+		fmt.Sprintf("%q is not a %s in any supported market (%s).", value.Value, v.what, strings.Join(marketCodes(), ", ")),
+	)
+}